@@ -11,6 +11,10 @@ type messageIdParams struct {
 	MessageId uint64 `form:"messageId"`
 }
 
+type scheduledMessageIdParams struct {
+	Id uint64 `form:"id"`
+}
+
 func chatApis(g *gin.RouterGroup) {
 	g.POST("/send", func(c *gin.Context) {
 		var d dto.SendMessageDto
@@ -31,6 +35,156 @@ func chatApis(g *gin.RouterGroup) {
 		logic.ChatRevokeMessage(myId, p.MessageId)
 		ok(c)
 	})
+	g.POST("/edit", func(c *gin.Context) {
+		var d dto.EditMessageDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatEditMessage(myId, &d)
+		ok(c)
+	})
+	g.GET("/edits", func(c *gin.Context) {
+		var p messageIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.ChatGetMessageEdits(myId, p.MessageId))
+	})
+	g.POST("/reaction", func(c *gin.Context) {
+		var d dto.ReactMessageDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatAddReaction(myId, &d)
+		ok(c)
+	})
+	g.POST("/reaction/remove", func(c *gin.Context) {
+		var p messageIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatRemoveReaction(myId, p.MessageId)
+		ok(c)
+	})
+	g.GET("/reactions", func(c *gin.Context) {
+		var p messageIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.ChatGetReactions(myId, p.MessageId))
+	})
+	g.POST("/received", func(c *gin.Context) {
+		var d dto.AckReceivedDto
+		mustBindQuery(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatAckReceived(myId, &d)
+		ok(c)
+	})
+	g.POST("/read", func(c *gin.Context) {
+		var d dto.MarkReadDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatMarkRead(myId, &d)
+		ok(c)
+	})
+	g.POST("/played", func(c *gin.Context) {
+		var p messageIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatMarkPlayed(myId, p.MessageId)
+		ok(c)
+	})
+	g.GET("/receipts", func(c *gin.Context) {
+		var p messageIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.ChatGetReceipts(myId, p.MessageId))
+	})
+	g.GET("/mentions", func(c *gin.Context) {
+		var p contactIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.ChatGetUnreadMentions(myId, p.ContactId))
+	})
+	g.GET("/search", func(c *gin.Context) {
+		var d dto.SearchMessageDto
+		mustBindQuery(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.ChatSearchMessages(myId, &d))
+	})
+	g.POST("/forward", func(c *gin.Context) {
+		var d dto.ForwardMessagesDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.ChatForwardMessages(myId, &d))
+	})
+	g.GET("/record", func(c *gin.Context) {
+		var d dto.RecordMessagesDto
+		mustBindQuery(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.ChatGetRecordMessages(myId, &d))
+	})
+	g.POST("/hide", func(c *gin.Context) {
+		var d dto.HideMessagesDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatHideMessages(myId, &d)
+		ok(c)
+	})
+	g.POST("/clear", func(c *gin.Context) {
+		var p contactIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatClearHistory(myId, p.ContactId)
+		ok(c)
+	})
+	g.POST("/pin", func(c *gin.Context) {
+		var p messageIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatPinMessage(myId, p.MessageId)
+		ok(c)
+	})
+	g.POST("/unpin", func(c *gin.Context) {
+		var p messageIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatUnpinMessage(myId, p.MessageId)
+		ok(c)
+	})
+	g.GET("/pins", func(c *gin.Context) {
+		var p contactIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.ChatGetPins(myId, p.ContactId))
+	})
+	g.POST("/ttl", func(c *gin.Context) {
+		var d dto.MessageTTLDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatSetMessageTTL(myId, &d)
+		ok(c)
+	})
+	g.POST("/schedule", func(c *gin.Context) {
+		var d dto.ScheduleMessageDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.ChatScheduleMessage(myId, &d))
+	})
+	g.GET("/scheduled", func(c *gin.Context) {
+		var p contactIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.ChatGetScheduledMessages(myId, p.ContactId))
+	})
+	g.POST("/scheduled/edit", func(c *gin.Context) {
+		var d dto.EditScheduledMessageDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.ChatEditScheduledMessage(myId, &d))
+	})
+	g.POST("/scheduled/cancel", func(c *gin.Context) {
+		var p scheduledMessageIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ChatCancelScheduledMessage(myId, p.Id)
+		ok(c)
+	})
 	g.GET("/sync", func(c *gin.Context) {
 		var d dto.SyncMessagesDto
 		mustBindQuery(c, &d)
@@ -44,6 +44,13 @@ func contactApis(g *gin.RouterGroup) {
 		myId := ctx.GetLoginUser(c).UserId
 		ok(c, logic.ContactGetAll(myId))
 	})
+	g.POST("/delete", func(c *gin.Context) {
+		var d dto.DeleteContactDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.ContactDelete(myId, &d)
+		ok(c)
+	})
 	g.GET("/members", func(c *gin.Context) {
 		var p contactIdParams
 		mustBindQuery(c, &p)
@@ -9,6 +9,9 @@ func fileApis(g *gin.RouterGroup) {
 	g.POST("/upload", func(c *gin.Context) {
 		ok(c, logic.FileUpload(c))
 	})
+	g.POST("/upload/audio", func(c *gin.Context) {
+		ok(c, logic.FileUploadAudio(c))
+	})
 	g.GET("/*path", func(c *gin.Context) {
 		path := c.Param("path")
 		logic.FileDownload(path, c)
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"ichat-go/ctx"
+	"ichat-go/logic"
+	"ichat-go/model/dto"
+)
+
+type groupIdParams struct {
+	GroupId uint64 `form:"groupId"`
+}
+
+type inviteIdParams struct {
+	InviteId uint64 `form:"inviteId"`
+}
+
+type joinRequestIdParams struct {
+	RequestId uint64 `form:"requestId"`
+}
+
+func groupApis(g *gin.RouterGroup) {
+	g.POST("/profile", func(c *gin.Context) {
+		var d dto.GroupProfileDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupUpdateProfile(myId, &d)
+		ok(c)
+	})
+	g.GET("/announcements", func(c *gin.Context) {
+		var d dto.QueryAnnouncementsDto
+		mustBindQuery(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.GroupGetAnnouncements(myId, &d))
+	})
+	g.POST("/settings", func(c *gin.Context) {
+		var d dto.GroupSettingsDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupUpdateSettings(myId, &d)
+		ok(c)
+	})
+	g.POST("/members/add", func(c *gin.Context) {
+		var d dto.GroupMembersDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupAddMembers(myId, &d)
+		ok(c)
+	})
+	g.POST("/members/remove", func(c *gin.Context) {
+		var d dto.RemoveGroupMembersDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupRemoveMembers(myId, &d)
+		ok(c)
+	})
+	g.POST("/admin/add", func(c *gin.Context) {
+		var d dto.GroupMemberDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupAddAdmin(myId, &d)
+		ok(c)
+	})
+	g.POST("/admin/remove", func(c *gin.Context) {
+		var d dto.GroupMemberDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupRemoveAdmin(myId, &d)
+		ok(c)
+	})
+	g.POST("/transfer", func(c *gin.Context) {
+		var d dto.GroupMemberDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupTransferOwner(myId, &d)
+		ok(c)
+	})
+	g.POST("/leave", func(c *gin.Context) {
+		var p groupIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupLeave(myId, p.GroupId)
+		ok(c)
+	})
+	g.POST("/dissolve", func(c *gin.Context) {
+		var p groupIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupDissolve(myId, p.GroupId)
+		ok(c)
+	})
+	g.POST("/mute/all", func(c *gin.Context) {
+		var d dto.MuteAllDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupMuteAll(myId, &d)
+		ok(c)
+	})
+	g.POST("/mute", func(c *gin.Context) {
+		var d dto.MuteMemberDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupMuteMember(myId, &d)
+		ok(c)
+	})
+	g.POST("/invite", func(c *gin.Context) {
+		var d dto.CreateGroupInviteDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.GroupCreateInvite(myId, &d))
+	})
+	g.GET("/invites", func(c *gin.Context) {
+		var p groupIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.GroupGetInvites(myId, p.GroupId))
+	})
+	g.POST("/invite/revoke", func(c *gin.Context) {
+		var p inviteIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupRevokeInvite(myId, p.InviteId)
+		ok(c)
+	})
+	g.GET("/invite/info", func(c *gin.Context) {
+		var d dto.GroupInviteCodeDto
+		mustBindQuery(c, &d)
+		ok(c, logic.GroupGetInviteInfo(d.Code))
+	})
+	g.POST("/join", func(c *gin.Context) {
+		var d dto.GroupInviteCodeDto
+		mustBindBody(c, &d)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.GroupJoinByInvite(myId, d.Code))
+	})
+	g.GET("/requests", func(c *gin.Context) {
+		var p groupIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		ok(c, logic.GroupGetJoinRequests(myId, p.GroupId))
+	})
+	g.POST("/request/accept", func(c *gin.Context) {
+		var p joinRequestIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupAcceptJoinRequest(myId, p.RequestId)
+		ok(c)
+	})
+	g.POST("/request/reject", func(c *gin.Context) {
+		var p joinRequestIdParams
+		mustBindQuery(c, &p)
+		myId := ctx.GetLoginUser(c).UserId
+		logic.GroupRejectJoinRequest(myId, p.RequestId)
+		ok(c)
+	})
+}
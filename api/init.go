@@ -12,6 +12,7 @@ func Init(r *gin.RouterGroup) {
 		"ws":       wsApis,
 		"call":     callApis,
 		"file":     fileApis,
+		"group":    groupApis,
 	}
 	for path, apis := range apiMap {
 		g := r.Group(path)
@@ -0,0 +1,7 @@
+package config
+
+import "time"
+
+type ChatConfig struct {
+	EditWindow time.Duration `yaml:"edit-window"`
+}
@@ -5,12 +5,14 @@ import (
 	"gopkg.in/yaml.v3"
 	"ichat-go/logging"
 	"os"
+	"time"
 )
 
 type Config struct {
 	Jwt       JwtConfig   `yaml:"jwt"`
 	Mysql     MysqlConfig `yaml:"mysql"`
 	Redis     RedisConfig `yaml:"redis"`
+	Chat      ChatConfig  `yaml:"chat"`
 	ApiPrefix string      `yaml:"api-prefix"`
 	LogLevel  string      `yaml:"log-level"`
 	UploadDir string      `yaml:"upload-dir"`
@@ -65,6 +67,9 @@ func fillDefault() {
 	if App.Redis.Port == 0 {
 		App.Redis.Port = 6379
 	}
+	if App.Chat.EditWindow == 0 {
+		App.Chat.EditWindow = time.Minute * 10
+	}
 }
 
 func Init() {
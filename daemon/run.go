@@ -1,7 +1,11 @@
 package daemon
 
-import "ichat-go/logic/call"
+import (
+	"ichat-go/logic"
+	"ichat-go/logic/call"
+)
 
 func Run() {
 	go call.MonitorLoop()
+	go logic.TaskLoop()
 }
@@ -1,5 +1,7 @@
 package db
 
+import "ichat-go/config"
+
 func Init() {
 	initMysql()
 	initRedis()
@@ -8,3 +10,17 @@ func Init() {
 func InitForTest() {
 	initRedis()
 }
+
+// InitMysqlForTest 未配置数据库时连接docker/dev/compose.yml中的开发数据库
+func InitMysqlForTest() {
+	if config.App.Mysql.Host == "" {
+		config.App.Mysql = config.MysqlConfig{
+			Host:     "localhost",
+			Port:     3306,
+			User:     "ichat",
+			Password: "ichat",
+			Database: "ichat",
+		}
+	}
+	initMysql()
+}
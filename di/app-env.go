@@ -48,3 +48,15 @@ func (a *app) GroupDao(t ...dao.Tx) dao.GroupDao {
 func (a *app) CallDao(t ...dao.Tx) dao.CallDao {
 	return dao.NewCallDao(a.txOrDB(t...))
 }
+
+func (a *app) MessageIndex(t ...dao.Tx) dao.MessageIndex {
+	return dao.NewMysqlMessageIndex(a.txOrDB(t...))
+}
+
+func (a *app) ScheduledMessageDao(t ...dao.Tx) dao.ScheduledMessageDao {
+	return dao.NewScheduledMessageDao(a.txOrDB(t...))
+}
+
+func (a *app) GroupInviteDao(t ...dao.Tx) dao.GroupInviteDao {
+	return dao.NewGroupInviteDao(a.txOrDB(t...))
+}
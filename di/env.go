@@ -15,6 +15,9 @@ type Env interface {
 	ChatDao(t ...dao.Tx) dao.ChatDao
 	GroupDao(t ...dao.Tx) dao.GroupDao
 	CallDao(t ...dao.Tx) dao.CallDao
+	MessageIndex(t ...dao.Tx) dao.MessageIndex
+	ScheduledMessageDao(t ...dao.Tx) dao.ScheduledMessageDao
+	GroupInviteDao(t ...dao.Tx) dao.GroupInviteDao
 }
 
 var env Env = &app{}
@@ -20,6 +20,22 @@ const (
 	CodeMessageRevokeExpired        = 2007
 	CodeMessageEmpty                = 2008
 	CodeMessageTypeNotSupported     = 2009
+	CodeMessageEditExpired          = 2010
+	CodeReplyMessageInvalid         = 2011
+	CodeMessageForwardInvalid       = 2012
+	CodeScheduleTimeInvalid         = 2013
+	CodeScheduledMessageNotPending  = 2014
+	CodePinLimitExceeded            = 2015
+	CodeGroupMemberLimitExceeded    = 2016
+	CodeGroupOwnerCannotLeave       = 2017
+	CodeGroupInviteInvalid          = 2018
+	CodeGroupAlreadyJoined          = 2019
+	CodeGroupJoinRequestExists      = 2020
+	CodeGroupJoinRequestNotPending  = 2021
+	CodeGroupDissolved              = 2022
+	CodeGroupMuted                  = 2023
+	CodeGroupMemberMuted            = 2024
+	CodeContactRemoved              = 2025
 
 	CodeCallStatusInvalid        = 3001
 	CodeCallMemberCountNotEnough = 3002
@@ -31,7 +47,8 @@ const (
 	CodeCallerBusy               = 3008
 	CodeCallStatusNotReady       = 3009
 
-	CodeSaveFileFailed = 4001
+	CodeSaveFileFailed        = 4001
+	CodeAudioTypeNotSupported = 4002
 )
 
 var UserNotFound = NewAppError(CodeUserNotFound, "用户不存在")
@@ -50,6 +67,22 @@ var ContactRequestExpired = NewAppError(CodeContactRequestExpired, "联系人申
 var MessageRevokeExpired = NewAppError(CodeMessageRevokeExpired, "只能撤回两分钟内的消息")
 var MessageEmpty = NewAppError(CodeMessageEmpty, "消息内容为空")
 var MessageTypeNotSupported = NewAppError(CodeMessageTypeNotSupported, "不支持的消息类型")
+var MessageEditExpired = NewAppError(CodeMessageEditExpired, "消息已超过可编辑时间")
+var ReplyMessageInvalid = NewAppError(CodeReplyMessageInvalid, "引用的消息不存在")
+var MessageForwardInvalid = NewAppError(CodeMessageForwardInvalid, "该消息不能转发")
+var ScheduleTimeInvalid = NewAppError(CodeScheduleTimeInvalid, "定时发送时间无效")
+var ScheduledMessageNotPending = NewAppError(CodeScheduledMessageNotPending, "定时消息已发送或已取消")
+var PinLimitExceeded = NewAppError(CodePinLimitExceeded, "置顶消息数量已达上限")
+var GroupMemberLimitExceeded = NewAppError(CodeGroupMemberLimitExceeded, "群成员数量已达上限")
+var GroupOwnerCannotLeave = NewAppError(CodeGroupOwnerCannotLeave, "群主不能退出群聊")
+var GroupInviteInvalid = NewAppError(CodeGroupInviteInvalid, "邀请链接无效或已过期")
+var GroupAlreadyJoined = NewAppError(CodeGroupAlreadyJoined, "已经是群成员")
+var GroupJoinRequestExists = NewAppError(CodeGroupJoinRequestExists, "入群申请已提交，请等待管理员审核")
+var GroupJoinRequestNotPending = NewAppError(CodeGroupJoinRequestNotPending, "入群申请不存在或已处理")
+var GroupDissolved = NewAppError(CodeGroupDissolved, "群聊已解散")
+var GroupMuted = NewAppError(CodeGroupMuted, "全员禁言中")
+var GroupMemberMuted = NewAppError(CodeGroupMemberMuted, "你已被禁言")
+var ContactRemoved = NewAppError(CodeContactRemoved, "对方已不是你的好友")
 
 var CallStatusInvalid = NewAppError(CodeCallStatusInvalid, "通话状态无效")
 var CallStatusNotReady = NewAppError(CodeCallStatusNotReady, "通话状态未准备")
@@ -62,6 +95,7 @@ var CallManagerLocked = NewAppError(CodeCallManagerLocked, "通话管理锁已
 var CallManagerNotFound = NewAppError(CodeCallManagerNotFound, "通话管理器不存在")
 
 var SaveFileFailed = NewAppError(CodeSaveFileFailed, "文件保存失败")
+var AudioTypeNotSupported = NewAppError(CodeAudioTypeNotSupported, "不支持的音频格式")
 
 func NewVerificationError(error string) AppError {
 	return NewAppError(CodeVerificationError, error)
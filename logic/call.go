@@ -27,6 +27,8 @@ func CallCreate(myId uint64, d *dto.CreateCallDto) uint64 {
 	}
 	contact := di.ENV().ContactDao().FindContactById(d.ContactId)
 	verifyContact(contact, myId)
+	checkContactWritable(contact)
+	checkGroupMute(contact, myId)
 	verifyContactMembers(contact, d.UserIds)
 	userIds := []uint64{myId}
 	for _, userId := range d.UserIds {
@@ -69,6 +71,15 @@ func manager(callId uint64) call.ManagerApi {
 	return mgr
 }
 
+// 结束聊天室中进行中的通话
+func endRoomCalls(roomId uint64, reason int) {
+	for _, callId := range di.ENV().CallDao().GetActiveCallIds(roomId) {
+		if mgr := call.FindManager(callId); mgr != nil {
+			mgr.End(reason)
+		}
+	}
+}
+
 func verifyCall(callId uint64) *entity.Call {
 	c := di.ENV().CallDao().FindCallById(callId)
 	if c == nil {
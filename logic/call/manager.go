@@ -288,6 +288,12 @@ func (m *manager) HeartBeat(userId uint64) {
 	m.delegate.UpdateUserTTL(userId)
 }
 
+// End 强制结束通话，如聊天已关闭
+func (m *manager) End(reason int) {
+	m.logger.Debugf("Call force end, reason: %d", reason)
+	m.callEnd(reason)
+}
+
 func (m *manager) callEnd(reason int) {
 	callStatus := m.delegate.CallStatus()
 	m.logger.Debugf("Call end, reason: %d, status: %d", reason, callStatus)
@@ -389,6 +395,8 @@ func (m *manager) handleAction(msg *sched.Message) {
 		m.Signaling(a.FromUserId, a.ToUserId, a.Message)
 	case actionTypeHeartBeat:
 		m.HeartBeat(heartBeatAction(msg))
+	case actionTypeEnd:
+		m.End(endAction(msg))
 	default:
 		m.logger.Errorf("Unknown action type: %d", msg.Type)
 	}
@@ -11,6 +11,7 @@ type ManagerApi interface {
 	Hangup(userId uint64)
 	Signaling(fromUserId, toUserId uint64, message string)
 	HeartBeat(userId uint64)
+	End(reason int)
 }
 
 type managerApi struct {
@@ -48,3 +49,7 @@ func (m *managerApi) Signaling(fromUserId, toUserId uint64, message string) {
 func (m *managerApi) HeartBeat(userId uint64) {
 	_ = m.mq.Push(newActionMessage(actionTypeHeartBeat, userId))
 }
+
+func (m *managerApi) End(reason int) {
+	_ = m.mq.Push(newActionMessage(actionTypeEnd, reason))
+}
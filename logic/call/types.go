@@ -41,6 +41,7 @@ const (
 	actionTypeHangup       = 4
 	actionTypeSignaling    = 5
 	actionTypeHeartBeat    = 6
+	actionTypeEnd          = 7
 )
 
 type actionSignaling struct {
@@ -82,6 +83,12 @@ func heartBeatAction(m *sched.Message) uint64 {
 	return userOnlineAction(m)
 }
 
+func endAction(m *sched.Message) int {
+	var reason int
+	_ = json.Unmarshal(m.Payload, &reason)
+	return reason
+}
+
 type wsMessage struct {
 	Type    int    `json:"type"`
 	Payload string `json:"payload"`
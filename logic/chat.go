@@ -2,7 +2,7 @@ package logic
 
 import (
 	"database/sql"
-	"fmt"
+	"ichat-go/config"
 	"ichat-go/di"
 	"ichat-go/errs"
 	"ichat-go/logic/notification"
@@ -20,59 +20,74 @@ type deliverItem struct {
 }
 
 type deliverCtx struct {
-	tx      dao.Tx
-	contact *entity.Contact
-	room    *entity.ChatRoom
-	m       *dto.ChatMessageDto
-	new     bool
-	items   []deliverItem
+	tx        dao.Tx
+	contact   *entity.Contact
+	room      *entity.ChatRoom
+	m         *dto.ChatMessageDto
+	new       bool
+	items     []deliverItem
+	mentioned []uint64
 }
 
 func (d *deliverCtx) findUserIds() []uint64 {
 	if d.contact != nil {
-		if d.contact.UserId != 0 {
-			return []uint64{d.contact.UserId, d.contact.OwnerId}
-		}
-		return di.ENV().GroupDao().GetMemberUserIds(d.contact.GroupId)
-	}
-	if d.room.Name[0] == 'u' {
-		var uid1, uid2 uint64
-		_, _ = fmt.Sscanf(d.room.Name, "u-%d-%d", &uid1, &uid2)
-		return []uint64{uid1, uid2}
+		return di.ENV().ChatDao(d.tx).GetRoomMemberUserIds(d.contact.RoomId)
 	}
-	var groupId uint64
-	_, _ = fmt.Sscanf(d.room.Name, "g-%d", &groupId)
-	return di.ENV().GroupDao().GetMemberUserIds(groupId)
+	return di.ENV().ChatDao(d.tx).GetRoomMemberUserIds(d.room.RoomId)
 }
 
-func (d *deliverCtx) createDelivery(uid uint64) {
-	delivery := &entity.MessageDelivery{
-		MessageId:  d.m.MessageId,
-		ReceiverId: uid,
-		Status:     entity.MessageDeliveryStatusSending,
+func (d *deliverCtx) isMentioned(uid uint64) bool {
+	if !d.new || uid == d.m.SenderId {
+		return false
 	}
-	di.ENV().ChatDao(d.tx).CreateDelivery(delivery)
-	d.items = append(d.items, deliverItem{userId: uid, deliveryId: delivery.Id})
+	return d.m.MentionAll || slices.Contains(d.m.Mentions, uid)
 }
 
+// 所有成员的投递记录一次批量插入，避免大群逐条插入
 func (d *deliverCtx) createDeliveries() {
-	for _, uid := range d.findUserIds() {
-		d.createDelivery(uid)
+	userIds := d.findUserIds()
+	deliveries := make([]*entity.MessageDelivery, 0, len(userIds))
+	for _, uid := range userIds {
+		delivery := &entity.MessageDelivery{
+			MessageId:  d.m.MessageId,
+			ReceiverId: uid,
+			Status:     entity.MessageDeliveryStatusSending,
+			Mentioned:  d.isMentioned(uid),
+		}
+		deliveries = append(deliveries, delivery)
+		if delivery.Mentioned {
+			d.mentioned = append(d.mentioned, uid)
+		}
+	}
+	di.ENV().ChatDao(d.tx).CreateDeliveries(deliveries)
+	for _, delivery := range deliveries {
+		d.items = append(d.items, deliverItem{userId: delivery.ReceiverId, deliveryId: delivery.Id})
 	}
 }
 
 func (d *deliverCtx) notifyUsers() {
+	var handled map[uint64]bool
+	if d.m.Call != nil && d.m.Call.Status != entity.CallStatusEnd {
+		handled = make(map[uint64]bool)
+		for _, uid := range di.ENV().CallDao().GetHandledUserIds(d.m.Call.CallId) {
+			handled[uid] = true
+		}
+	}
+	ms := make(map[uint64]*dto.ChatMessageDto, len(d.items))
 	for _, item := range d.items {
 		m := *d.m
 		if item.userId != m.SenderId {
 			m.LocalId = ""
 		}
 		m.DeliveryId = item.deliveryId
-		if m.Call != nil && m.Call.Status != entity.CallStatusEnd {
-			m.Call.Handled = di.ENV().CallDao(d.tx).IsHandled(m.Call.CallId, item.userId)
+		if handled != nil {
+			c := *m.Call
+			c.Handled = handled[item.userId]
+			m.Call = &c
 		}
-		notification.SendChatMessage(item.userId, &m, d.new)
+		ms[item.userId] = &m
 	}
+	notification.SendChatMessages(ms, d.new)
 }
 
 func (d *deliverCtx) deliver() {
@@ -81,6 +96,13 @@ func (d *deliverCtx) deliver() {
 	}
 	d.createDeliveries()
 	updateContactsLastMessage(d.tx, &d.m.ChatMessage)
+	if d.new {
+		contactDao := di.ENV().ContactDao(d.tx)
+		contactDao.IncreaseUnreadCount(d.m.RoomId, d.m.SenderId)
+		if len(d.mentioned) > 0 {
+			contactDao.IncreaseMentionCount(d.m.RoomId, d.mentioned)
+		}
+	}
 	go d.notifyUsers()
 }
 
@@ -89,17 +111,28 @@ func messageType(e *entity.ChatMessage) int {
 		return entity.ChatMessageTypeText
 	} else if e.Image != "" || e.Thumbnail != "" {
 		return entity.ChatMessageTypeImage
+	} else if e.File != "" || e.FileName != "" {
+		return entity.ChatMessageTypeFile
+	} else if e.Audio != "" {
+		return entity.ChatMessageTypeAudio
 	} else if e.CallId != 0 {
 		return entity.ChatMessageTypeCall
+	} else if e.Record != nil {
+		return entity.ChatMessageTypeRecord
+	} else if e.System != nil {
+		return entity.ChatMessageTypeSystem
 	}
 	panic(errs.MessageTypeNotSupported)
 }
 
 func onMessageUpdated(tx dao.Tx, m *entity.ChatMessage) {
-	d := messageToDto(m, true)
+	notifyMessageUpdated(tx, messageToDto(m, true))
+}
+
+func notifyMessageUpdated(tx dao.Tx, d *dto.ChatMessageDto) {
 	ctx := deliverCtx{
 		tx:   tx,
-		room: di.ENV().ChatDao().FindRoomById(m.RoomId),
+		room: di.ENV().ChatDao().FindRoomById(d.RoomId),
 		m:    d,
 		new:  false,
 	}
@@ -107,27 +140,90 @@ func onMessageUpdated(tx dao.Tx, m *entity.ChatMessage) {
 }
 
 func checkMessageForm(d *dto.SendMessageDto) {
-	if d.Text == "" && d.Thumbnail == "" && d.Image == "" {
+	if d.Text == "" && d.Thumbnail == "" && d.Image == "" &&
+		d.File == "" && d.FileName == "" && d.Audio == "" {
 		panic(errs.MessageEmpty)
 	}
+	if (d.File != "" || d.FileSize != 0 || d.MimeType != "") && d.FileName == "" {
+		panic(errs.NewAppError(errs.CodeBadRequest, "文件名不能为空"))
+	}
+	if d.Audio != "" && !isUploadedAudio(d.Audio) {
+		panic(errs.AudioTypeNotSupported)
+	}
+	if d.Audio != "" && d.Duration == 0 {
+		panic(errs.NewAppError(errs.CodeBadRequest, "语音时长不能为空"))
+	}
+}
+
+func checkReplyMessage(contact *entity.Contact, replyToId uint64) {
+	if replyToId == 0 {
+		return
+	}
+	r := di.ENV().ChatDao().FindMessageById(replyToId)
+	if r == nil || r.RoomId != contact.RoomId || r.Revoked {
+		panic(errs.ReplyMessageInvalid)
+	}
+}
+
+// checkMentions 校验@的成员，返回去重后的成员id
+func checkMentions(contact *entity.Contact, senderId uint64, d *dto.SendMessageDto) []uint64 {
+	if len(d.MentionIds) == 0 && !d.MentionAll {
+		return nil
+	}
+	if contact.GroupId == 0 {
+		panic(errs.NewAppError(errs.CodeBadRequest, "只能在群聊中@成员"))
+	}
+	if d.MentionAll {
+		checkGroupPermission(senderId, contact.GroupId, groupActionMentionAll)
+	}
+	verifyRoomMembers(contact.RoomId, d.MentionIds)
+	var ids []uint64
+	for _, id := range d.MentionIds {
+		if id != senderId && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 func ChatSendMessage(senderId uint64, d *dto.SendMessageDto) *dto.ChatMessageDto {
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	return sendMessage(tx, senderId, d)
+}
+
+// 发送消息，定时消息到期时也在这里发送
+func sendMessage(tx dao.Tx, senderId uint64, d *dto.SendMessageDto) *dto.ChatMessageDto {
 	checkMessageForm(d)
 	contact := di.ENV().ContactDao().FindContactById(d.ContactId)
 	verifyContact(contact, senderId)
-	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
-	defer commitOrRollback(tx)
+	checkContactWritable(contact)
+	checkGroupMute(contact, senderId)
+	checkReplyMessage(contact, d.ReplyToId)
+	mentions := checkMentions(contact, senderId, d)
 	chatDao := di.ENV().ChatDao(tx)
 	message := &entity.ChatMessage{
-		RoomId:    contact.RoomId,
-		SenderId:  senderId,
-		Text:      d.Text,
-		Image:     d.Image,
-		Thumbnail: d.Thumbnail,
+		RoomId:     contact.RoomId,
+		SenderId:   senderId,
+		Text:       d.Text,
+		Image:      d.Image,
+		Thumbnail:  d.Thumbnail,
+		File:       d.File,
+		FileName:   d.FileName,
+		FileSize:   d.FileSize,
+		MimeType:   d.MimeType,
+		Audio:      d.Audio,
+		Duration:   d.Duration,
+		Waveform:   d.Waveform,
+		ReplyToId:  d.ReplyToId,
+		Mentions:   mentions,
+		MentionAll: d.MentionAll,
 	}
 	message.Type = messageType(message)
+	setMessageExpireAt(contact, message)
 	chatDao.CreateMessage(message)
+	indexMessage(tx, message)
+	scheduleMessageExpire(message)
 	m := messageToDto(message, false)
 	m.LocalId = d.LocalId // 发送消息时，将本地消息ID返回给客户端
 	ctx := deliverCtx{
@@ -142,12 +238,17 @@ func ChatSendMessage(senderId uint64, d *dto.SendMessageDto) *dto.ChatMessageDto
 
 func ChatDelayUpload(myId uint64, d *dto.DelayUploadDto) {
 	m := di.ENV().ChatDao().FindMessageById(d.MessageId)
-	if m == nil || m.SenderId != myId ||
-		m.Type != entity.ChatMessageTypeImage || m.Image != "" ||
-		m.Revoked {
+	if m == nil || m.SenderId != myId || m.Revoked {
+		panic(errs.Forbidden)
+	}
+	switch {
+	case m.Type == entity.ChatMessageTypeImage && m.Image == "" && d.Image != "":
+		m.Image = d.Image
+	case m.Type == entity.ChatMessageTypeFile && m.File == "" && d.File != "":
+		m.File = d.File
+	default:
 		panic(errs.Forbidden)
 	}
-	m.Image = d.Image
 	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	defer commitOrRollback(tx)
 	di.ENV().ChatDao(tx).UpdateMessage(m)
@@ -155,13 +256,71 @@ func ChatDelayUpload(myId uint64, d *dto.DelayUploadDto) {
 }
 
 func messageToDto(e *entity.ChatMessage, checkCall bool) *dto.ChatMessageDto {
-	d := &dto.ChatMessageDto{
-		ChatMessage: *e,
+	return messagesToDtos([]*entity.ChatMessage{e}, checkCall)[0]
+}
+
+func messagesToDtos(messages []*entity.ChatMessage, checkCall bool) []*dto.ChatMessageDto {
+	list := make([]*dto.ChatMessageDto, 0, len(messages))
+	replyIds := make([]uint64, 0)
+	for _, e := range messages {
+		d := &dto.ChatMessageDto{
+			ChatMessage: *e,
+		}
+		if checkCall && e.CallId != 0 {
+			d.Call = findCall(e.CallId)
+		}
+		if e.ReplyToId != 0 {
+			replyIds = append(replyIds, e.ReplyToId)
+		}
+		list = append(list, d)
+	}
+	fillReplies(list, replyIds)
+	return list
+}
+
+// 引用快照在读取时批量生成，保证被引用消息撤回、编辑后内容一致
+func fillReplies(list []*dto.ChatMessageDto, replyIds []uint64) {
+	if len(replyIds) == 0 {
+		return
+	}
+	replies := make(map[uint64]*dto.ReplyMessageDto)
+	for _, r := range di.ENV().ChatDao().FindMessagesByIds(replyIds) {
+		replies[r.MessageId] = replyMessageToDto(r)
 	}
-	if checkCall && e.CallId != 0 {
-		d.Call = findCall(e.CallId)
+	for _, d := range list {
+		if d.ReplyToId != 0 {
+			d.Reply = replies[d.ReplyToId]
+		}
 	}
-	return d
+}
+
+func replyMessageToDto(m *entity.ChatMessage) *dto.ReplyMessageDto {
+	return &dto.ReplyMessageDto{
+		MessageId: m.MessageId,
+		SenderId:  m.SenderId,
+		Type:      m.Type,
+		Preview:   describeChatMessage(m),
+		Revoked:   m.Revoked,
+	}
+}
+
+// 通知聊天室成员更新引用了该消息的快照，不重新投递引用消息；事务未提交，快照直接由m生成
+func onRepliedMessageUpdated(tx dao.Tx, m *entity.ChatMessage) {
+	messageIds := di.ENV().ChatDao(tx).GetReplyMessageIds(m.MessageId)
+	if len(messageIds) == 0 {
+		return
+	}
+	r := &dto.ReplyUpdatedDto{
+		RoomId:     m.RoomId,
+		MessageIds: messageIds,
+		Reply:      replyMessageToDto(m),
+	}
+	userIds := di.ENV().ChatDao(tx).GetRoomMemberUserIds(m.RoomId)
+	go func() {
+		for _, uid := range userIds {
+			notification.SendReplyUpdated(uid, r)
+		}
+	}()
 }
 
 func ChatGetHistoryMessages(myId uint64, d *dto.QueryChatMessageDto) []*dto.ChatMessageDto {
@@ -170,46 +329,115 @@ func ChatGetHistoryMessages(myId uint64, d *dto.QueryChatMessageDto) []*dto.Chat
 	}
 	contact := di.ENV().ContactDao().FindContactById(d.ContactId)
 	verifyContact(contact, myId)
-	messages := di.ENV().ChatDao().GetMessages(contact.RoomId, d.LastMessageId, d.Limit)
-	list := make([]*dto.ChatMessageDto, 0, len(messages))
-	for _, e := range messages {
-		list = append(list, messageToDto(e, true))
-	}
+	messages := di.ENV().ChatDao().GetMessages(myId, contact.RoomId, d.LastMessageId, d.Limit)
+	list := messagesToDtos(messages, true)
+	fillReactionCounts(list)
 	slices.Reverse(list)
 	return list
 }
 
 func ChatRevokeMessage(myId uint64, messageId uint64) {
 	m := di.ENV().ChatDao().FindMessageById(messageId)
-	if m == nil || m.SenderId != myId || m.Type == entity.ChatMessageTypeCall {
+	if m == nil || m.SenderId != myId ||
+		m.Type == entity.ChatMessageTypeCall || m.Type == entity.ChatMessageTypeSystem {
 		panic(errs.Forbidden)
 	}
 	if time.Now().Add(-time.Minute * 2).After(m.CreatedAt) {
 		panic(errs.MessageRevokeExpired)
 	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	eraseMessage(tx, m)
+}
+
+// 清空消息内容并通知客户端，撤回和消息过期共用
+func eraseMessage(tx dao.Tx, m *entity.ChatMessage) {
 	m.Text = ""
 	m.Image = ""
 	m.Thumbnail = ""
+	m.File = ""
+	m.FileName = ""
+	m.FileSize = 0
+	m.MimeType = ""
+	m.Audio = ""
+	m.Duration = 0
+	m.Waveform = nil
+	m.Record = nil
 	m.Revoked = true
+	chatDao := di.ENV().ChatDao(tx)
+	chatDao.UpdateMessage(m)
+	chatDao.DeleteMessageEdits(m.MessageId) // 撤回后不保留历史版本
+	indexMessage(tx, m)
+	onMessageUpdated(tx, m)
+	onRepliedMessageUpdated(tx, m)
+}
+
+func ChatEditMessage(myId uint64, d *dto.EditMessageDto) {
+	m := di.ENV().ChatDao().FindMessageById(d.MessageId)
+	if m == nil || m.SenderId != myId || m.Type != entity.ChatMessageTypeText || m.Revoked {
+		panic(errs.Forbidden)
+	}
+	if time.Now().Add(-config.App.Chat.EditWindow).After(m.CreatedAt) {
+		panic(errs.MessageEditExpired)
+	}
+	if m.Text == d.Text {
+		return
+	}
+	// 已退出或被移出群聊时不能再编辑
+	c := di.ENV().ContactDao().FindContactByRoomId(myId, m.RoomId)
+	if c == nil {
+		panic(errs.Forbidden)
+	}
+	checkContactWritable(c)
 	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
 	defer commitOrRollback(tx)
-	di.ENV().ChatDao(tx).UpdateMessage(m)
+	chatDao := di.ENV().ChatDao(tx)
+	chatDao.CreateMessageEdit(&entity.ChatMessageEdit{
+		MessageId: m.MessageId,
+		Text:      m.Text,
+	})
+	now := time.Now()
+	m.Text = d.Text
+	m.EditedAt = &now
+	chatDao.UpdateMessage(m)
+	indexMessage(tx, m)
 	onMessageUpdated(tx, m)
+	onRepliedMessageUpdated(tx, m)
+}
+
+// ChatGetUnreadMentions 查询会话中@我且未读的消息
+func ChatGetUnreadMentions(myId uint64, contactId uint64) []*dto.ChatMessageDto {
+	contact := di.ENV().ContactDao().FindContactById(contactId)
+	verifyContact(contact, myId)
+	messages := di.ENV().ChatDao().GetUnreadMentions(myId, contact.RoomId)
+	return messagesToDtos(messages, false)
+}
+
+func ChatGetMessageEdits(myId uint64, messageId uint64) []*entity.ChatMessageEdit {
+	findVisibleMessage(myId, messageId)
+	return di.ENV().ChatDao().GetMessageEdits(messageId)
+}
+
+// findVisibleMessage 查找消息并校验用户是否在消息所属的聊天室中
+func findVisibleMessage(myId uint64, messageId uint64) *entity.ChatMessage {
+	m := di.ENV().ChatDao().FindMessageById(messageId)
+	if m == nil || di.ENV().ContactDao().FindContactByRoomId(myId, m.RoomId) == nil {
+		panic(errs.Forbidden)
+	}
+	return m
 }
 
 func ChatSyncMessages(myId uint64, d *dto.SyncMessagesDto) []*dto.ChatMessageDto {
-	messages := di.ENV().ChatDao().GetDeliveries(myId, d.Last, d.Synced, d.Limit)
-	results := make([]*dto.ChatMessageDto, 0)
-	for _, e := range messages {
-		d := &dto.ChatMessageDto{
-			ChatMessage: e.ChatMessage,
-			DeliveryId:  e.DeliveryId,
-		}
-		if e.ChatMessage.CallId != 0 {
-			d.Call = findCall(e.ChatMessage.CallId)
-		}
-		results = append(results, d)
+	deliveries := di.ENV().ChatDao().GetDeliveries(myId, d.Last, d.Synced, d.Limit)
+	messages := make([]*entity.ChatMessage, 0, len(deliveries))
+	for _, e := range deliveries {
+		messages = append(messages, &e.ChatMessage)
+	}
+	results := messagesToDtos(messages, true)
+	for i, e := range deliveries {
+		results[i].DeliveryId = e.DeliveryId
 	}
+	fillReactionCounts(results)
 	return results
 }
 
@@ -227,6 +455,9 @@ func updateContactsLastMessage(tx dao.Tx, m *entity.ChatMessage) {
 }
 
 func describeChatMessage(e *entity.ChatMessage) string {
+	if e.Expired {
+		return "[消息已过期]"
+	}
 	if e.Revoked {
 		return "[消息已撤回]"
 	}
@@ -235,9 +466,26 @@ func describeChatMessage(e *entity.ChatMessage) string {
 		return strs.TakeFirstN(e.Text, 20, true)
 	case entity.ChatMessageTypeImage:
 		return "[图片]"
+	case entity.ChatMessageTypeFile:
+		return "[文件] " + e.FileName
+	case entity.ChatMessageTypeAudio:
+		return "[语音]"
 	case entity.ChatMessageTypeCall:
 		return "[通话]"
+	case entity.ChatMessageTypeRecord:
+		return "[聊天记录]"
+	case entity.ChatMessageTypeSystem:
+		return describeSystemMessage(e)
 	default:
 		panic("invalid message type")
 	}
 }
+
+func init() {
+	// 依赖注入，避免循环依赖
+	notification.SetCallbacks(notification.Callbacks{
+		AckReceived: ChatAckReceived,
+		MarkRead:    ChatMarkRead,
+		Typing:      ChatTyping,
+	})
+}
@@ -1,10 +1,12 @@
 package logic
 
 import (
+	"database/sql"
 	"gorm.io/gorm"
 	"ichat-go/di"
 	"ichat-go/errs"
 	"ichat-go/logic/notification"
+	"ichat-go/model/dao"
 	"ichat-go/model/dto"
 	"ichat-go/model/entity"
 	"ichat-go/utils"
@@ -15,7 +17,7 @@ func ContactRequestAddUser(myId uint64, d *dto.AddUserContactDto) *entity.Contac
 	userId := d.UserId
 	utils.Assert(myId != userId)
 	contact := di.ENV().ContactDao().FindUserContact(myId, userId)
-	if contact != nil {
+	if contact != nil && contact.Status != entity.ContactStatusRemoved {
 		panic(errs.ContactExists)
 	}
 	user := di.ENV().UserDao().FindUserByUserId(userId)
@@ -58,6 +60,12 @@ func ContactRequestGetAllPending(myId uint64) []*entity.ContactRequest {
 }
 
 func createUserContact(tx *gorm.DB, uid1, uid2 uint64) *entity.Contact {
+	// 删除好友后重新添加，恢复原联系人，继续使用原聊天室
+	if contact := di.ENV().ContactDao(tx).FindUserContact(uid1, uid2); contact != nil {
+		contact.Status = entity.ContactStatusNormal
+		di.ENV().ContactDao(tx).UpdateStatus(contact.ContactId, contact.Status)
+		return contact
+	}
 	contact := &entity.Contact{
 		OwnerId: uid1,
 		UserId:  uid2,
@@ -112,11 +120,41 @@ func ContactRequestReject(myId uint64, requestId uint64) {
 	contactDao.UpdateContactRequestStatus(requestId, entity.ContactRequestStatusRejected)
 }
 
+// ContactDelete 删除好友，双方联系人都变为已删除，不能再发送消息和发起通话，聊天记录默认保留
+func ContactDelete(myId uint64, d *dto.DeleteContactDto) {
+	contact := di.ENV().ContactDao().FindContactById(d.ContactId)
+	verifyContact(contact, myId)
+	if contact.UserId == 0 {
+		panic(errs.NewAppError(errs.CodeBadRequest, "只能删除用户联系人"))
+	}
+	if contact.Status == entity.ContactStatusRemoved {
+		panic(errs.ContactRemoved)
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	di.ENV().ContactDao(tx).UpdateStatusByRoomId(contact.RoomId, entity.ContactStatusRemoved)
+	if d.ClearHistory {
+		lastMessageId := di.ENV().ChatDao(tx).FindLastMessageId(contact.RoomId)
+		if lastMessageId > contact.ClearedMessageId {
+			clearHistory(tx, contact, lastMessageId)
+		}
+	}
+	tx.Commit()
+	endRoomCalls(contact.RoomId, entity.CallEndReasonClosed)
+	for _, c := range di.ENV().ContactDao().GetContactsByRoomId(contact.RoomId) {
+		notification.SendContactStatus(c.OwnerId, &dto.ContactStatusDto{
+			ContactId: c.ContactId,
+			RoomId:    c.RoomId,
+			Status:    c.Status,
+		})
+	}
+}
+
 func ContactGetAll(myId uint64) []*entity.Contact {
 	return di.ENV().ContactDao().GetAll(myId)
 }
 
-func ContactGetMembers(myId uint64, contactId uint64) []*entity.User {
+func ContactGetMembers(myId uint64, contactId uint64) []*dao.Member {
 	c := di.ENV().ContactDao().FindContactById(contactId)
 	verifyContact(c, myId)
 	if c.UserId != 0 {
@@ -125,10 +163,10 @@ func ContactGetMembers(myId uint64, contactId uint64) []*entity.User {
 	return getGroupMembers(c.GroupId)
 }
 
-func getUserContactMembers(c *entity.Contact) []*entity.User {
+func getUserContactMembers(c *entity.Contact) []*dao.Member {
 	user1 := di.ENV().UserDao().FindUserByUserId(c.OwnerId)
 	user2 := di.ENV().UserDao().FindUserByUserId(c.UserId)
-	return []*entity.User{user1, user2}
+	return []*dao.Member{{User: *user1}, {User: *user2}}
 }
 
 func verifyContact(c *entity.Contact, myId uint64) {
@@ -141,12 +179,22 @@ func verifyContact(c *entity.Contact, myId uint64) {
 	utils.Assert(c.RoomId != 0)
 }
 
+// 校验联系人可以发送消息、发起通话
+func checkContactWritable(c *entity.Contact) {
+	switch c.Status {
+	case entity.ContactStatusReadOnly:
+		panic(errs.GroupDissolved)
+	case entity.ContactStatusRemoved:
+		panic(errs.ContactRemoved)
+	}
+}
+
 func verifyContactMembers(c *entity.Contact, userIds []uint64) {
 	if c.UserId != 0 {
 		if len(userIds) > 1 || (len(userIds) == 1 && userIds[0] != c.UserId) {
 			panic(errs.Forbidden)
 		}
 	} else if c.GroupId != 0 {
-		verifyGroupMembers(c.GroupId, userIds)
+		verifyRoomMembers(c.RoomId, userIds)
 	}
 }
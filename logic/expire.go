@@ -0,0 +1,60 @@
+package logic
+
+import (
+	"database/sql"
+	"ichat-go/di"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+	"time"
+)
+
+// 联系人设置了消息自动删除时间时，设置新消息的过期时间
+func setMessageExpireAt(contact *entity.Contact, m *entity.ChatMessage) {
+	if contact.MessageTTL <= 0 {
+		return
+	}
+	expireAt := time.Now().Add(time.Duration(contact.MessageTTL) * time.Second)
+	m.ExpireAt = &expireAt
+}
+
+func scheduleMessageExpire(m *entity.ChatMessage) {
+	if m.ExpireAt != nil {
+		scheduleTask(taskTypeMessageExpire, m.MessageId, *m.ExpireAt)
+	}
+}
+
+// ChatSetMessageTTL 修改聊天室的消息自动删除时间，只对之后发送的消息生效
+func ChatSetMessageTTL(myId uint64, d *dto.MessageTTLDto) {
+	contact := di.ENV().ContactDao().FindContactById(d.ContactId)
+	verifyContact(contact, myId)
+	checkContactWritable(contact)
+	if contact.GroupId != 0 {
+		checkGroupPermission(myId, contact.GroupId, groupActionSetMessageTTL)
+	}
+	if contact.MessageTTL == d.TTL {
+		return
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	di.ENV().ContactDao(tx).UpdateMessageTTL(contact.RoomId, d.TTL)
+	sendSystemMessage(tx, contact, myId, &entity.SystemMessage{
+		Event:    entity.SystemEventMessageTTL,
+		Duration: d.TTL,
+	})
+}
+
+// 消息到期后清空内容，和撤回一样通知客户端
+func expireMessage(messageId uint64) {
+	m := di.ENV().ChatDao().FindMessageById(messageId)
+	if m == nil || m.Expired {
+		return
+	}
+	m.Expired = true
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	eraseMessage(tx, m)
+}
+
+func init() {
+	taskHandlers[taskTypeMessageExpire] = expireMessage
+}
@@ -1,10 +1,14 @@
 package logic
 
 import (
+	"github.com/gabriel-vasile/mimetype"
 	"github.com/gin-gonic/gin"
 	"ichat-go/config"
 	"ichat-go/errs"
+	"mime/multipart"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -19,7 +23,25 @@ func fullPath(path string) string {
 	return filepath.Join(config.App.UploadDir, path)
 }
 
-func FileUpload(c *gin.Context) string {
+// 语音文件名前缀，发送语音消息时用于校验语音来自语音上传接口
+const audioFilePrefix = "audio-"
+
+// 语音消息允许的音频格式，webm会被检测为video/webm，按别名匹配
+var audioMimeTypes = []string{
+	"audio/mpeg", "audio/aac", "audio/mp4", "audio/x-m4a", "audio/ogg",
+	"audio/wav", "audio/amr", "audio/webm", "audio/flac",
+}
+
+func isAudioMimeType(mtype *mimetype.MIME) bool {
+	for _, t := range audioMimeTypes {
+		if mtype.Is(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func formFile(c *gin.Context) *multipart.FileHeader {
 	file, err := c.FormFile("file")
 	if err != nil {
 		panic(errs.NewAppError(errs.CodeBadRequest, "文件参数错误"))
@@ -27,10 +49,13 @@ func FileUpload(c *gin.Context) string {
 	if file.Size > 1024*1024*10 {
 		panic(errs.NewAppError(errs.CodeBadRequest, "文件大小不能超过10M"))
 	}
-	ext := filepath.Ext(file.Filename)
+	return file
+}
+
+func saveFile(c *gin.Context, file *multipart.FileHeader, prefix string, ext string) string {
 	savePath := ""
 	for {
-		savePath = time.Now().Format("2006-01-02T150405.000") + ext
+		savePath = prefix + time.Now().Format("2006-01-02T150405.000") + ext
 		if !fileExists(fullPath(savePath)) {
 			break
 		}
@@ -43,6 +68,35 @@ func FileUpload(c *gin.Context) string {
 	return "file/" + savePath
 }
 
+func FileUpload(c *gin.Context) string {
+	file := formFile(c)
+	return saveFile(c, file, "", filepath.Ext(file.Filename))
+}
+
+// FileUploadAudio 上传语音，按文件内容检测格式，扩展名以检测结果为准
+func FileUploadAudio(c *gin.Context) string {
+	file := formFile(c)
+	f, err := file.Open()
+	if err != nil {
+		panic(errs.NewAppError(errs.CodeBadRequest, "文件参数错误"))
+	}
+	mtype, err := mimetype.DetectReader(f)
+	_ = f.Close()
+	if err != nil || !isAudioMimeType(mtype) {
+		panic(errs.AudioTypeNotSupported)
+	}
+	return saveFile(c, file, audioFilePrefix, mtype.Extension())
+}
+
+// 语音地址必须指向语音上传接口保存的文件，避免绕过音频格式校验
+func isUploadedAudio(audio string) bool {
+	u, err := url.Parse(audio)
+	if err != nil {
+		return false
+	}
+	return path.Base(path.Dir(u.Path)) == "file" && strings.HasPrefix(path.Base(u.Path), audioFilePrefix)
+}
+
 func checkValid(path string) string {
 	root, _ := filepath.Abs(fullPath(""))
 	abs, _ := filepath.Abs(fullPath(path))
@@ -0,0 +1,151 @@
+package logic
+
+import (
+	"database/sql"
+	"ichat-go/di"
+	"ichat-go/errs"
+	"ichat-go/model/dao"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+	"slices"
+)
+
+// 合并转发卡片最多预览的消息数
+const recordPreviewCount = 4
+
+func checkForwardMessages(myId uint64, messageIds []uint64) []*entity.ChatMessage {
+	messageIds = slices.Clone(messageIds)
+	slices.Sort(messageIds)
+	messageIds = slices.Compact(messageIds) // 重复的消息id只转发一次
+	// 已删除或已清空的消息不能转发
+	messages := di.ENV().ChatDao().FindVisibleMessagesByIds(myId, messageIds)
+	if len(messages) != len(messageIds) {
+		panic(errs.MessageForwardInvalid)
+	}
+	visibleRooms := make(map[uint64]bool)
+	for _, m := range messages {
+		if !visibleRooms[m.RoomId] {
+			if di.ENV().ContactDao().FindContactByRoomId(myId, m.RoomId) == nil {
+				panic(errs.Forbidden)
+			}
+			visibleRooms[m.RoomId] = true
+		}
+		if m.Revoked || m.Type == entity.ChatMessageTypeCall || m.Type == entity.ChatMessageTypeSystem ||
+			(m.Type == entity.ChatMessageTypeImage && m.Image == "") ||
+			(m.Type == entity.ChatMessageTypeFile && m.File == "") {
+			panic(errs.MessageForwardInvalid)
+		}
+	}
+	return messages
+}
+
+func copyMessage(m *entity.ChatMessage) *entity.ChatMessage {
+	forwardedFrom := m.ForwardedFrom
+	if forwardedFrom == 0 {
+		forwardedFrom = m.SenderId
+	}
+	return &entity.ChatMessage{
+		Type:          m.Type,
+		Text:          m.Text,
+		Image:         m.Image,
+		Thumbnail:     m.Thumbnail,
+		File:          m.File,
+		FileName:      m.FileName,
+		FileSize:      m.FileSize,
+		MimeType:      m.MimeType,
+		Audio:         m.Audio,
+		Duration:      m.Duration,
+		Waveform:      m.Waveform,
+		Record:        m.Record,
+		ForwardedFrom: forwardedFrom,
+	}
+}
+
+func recordMessage(messages []*entity.ChatMessage) *entity.ChatMessage {
+	r := &entity.MessageRecord{}
+	for i, m := range messages {
+		r.MessageIds = append(r.MessageIds, m.MessageId)
+		if i < recordPreviewCount {
+			r.Items = append(r.Items, &entity.MessageRecordItem{
+				SenderId:  m.SenderId,
+				Type:      m.Type,
+				Preview:   describeChatMessage(m),
+				CreatedAt: m.CreatedAt,
+			})
+		}
+	}
+	return &entity.ChatMessage{
+		Type:   entity.ChatMessageTypeRecord,
+		Record: r,
+	}
+}
+
+func forwardMessage(tx dao.Tx, myId uint64, contact *entity.Contact, message *entity.ChatMessage) *dto.ChatMessageDto {
+	message.RoomId = contact.RoomId
+	message.SenderId = myId
+	setMessageExpireAt(contact, message)
+	di.ENV().ChatDao(tx).CreateMessage(message)
+	indexMessage(tx, message)
+	scheduleMessageExpire(message)
+	m := messageToDto(message, false)
+	ctx := deliverCtx{
+		tx:      tx,
+		contact: contact,
+		m:       m,
+		new:     true,
+	}
+	ctx.deliver()
+	return m
+}
+
+func ChatForwardMessages(myId uint64, d *dto.ForwardMessagesDto) []*dto.ChatMessageDto {
+	messages := checkForwardMessages(myId, d.MessageIds)
+	contacts := make([]*entity.Contact, 0, len(d.ContactIds))
+	for _, contactId := range d.ContactIds {
+		contact := di.ENV().ContactDao().FindContactById(contactId)
+		verifyContact(contact, myId)
+		checkContactWritable(contact)
+		checkGroupMute(contact, myId)
+		contacts = append(contacts, contact)
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	results := make([]*dto.ChatMessageDto, 0)
+	for _, contact := range contacts {
+		if d.Merged {
+			results = append(results, forwardMessage(tx, myId, contact, recordMessage(messages)))
+			continue
+		}
+		for _, m := range messages {
+			results = append(results, forwardMessage(tx, myId, contact, copyMessage(m)))
+		}
+	}
+	return results
+}
+
+func isRecordMessage(m *entity.ChatMessage) bool {
+	return m != nil && m.Type == entity.ChatMessageTypeRecord && m.Record != nil
+}
+
+// 查找聊天记录消息，嵌套的聊天记录不在用户所在的聊天室，需要逐层校验包含在外层聊天记录中
+func findRecordMessage(myId uint64, d *dto.RecordMessagesDto) *entity.ChatMessage {
+	ids := append(slices.Clone(d.Path), d.MessageId)
+	m := findVisibleMessage(myId, ids[0])
+	for _, id := range ids[1:] {
+		if !isRecordMessage(m) || !slices.Contains(m.Record.MessageIds, id) {
+			panic(errs.Forbidden)
+		}
+		m = di.ENV().ChatDao().FindMessageById(id)
+	}
+	if !isRecordMessage(m) {
+		panic(errs.Forbidden)
+	}
+	return m
+}
+
+// ChatGetRecordMessages 查询合并转发的聊天记录详情
+func ChatGetRecordMessages(myId uint64, d *dto.RecordMessagesDto) []*dto.ChatMessageDto {
+	m := findRecordMessage(myId, d)
+	messages := di.ENV().ChatDao().FindMessagesByIds(m.Record.MessageIds)
+	return messagesToDtos(messages, false)
+}
@@ -1,17 +1,21 @@
 package logic
 
 import (
-	"fmt"
-	"github.com/google/uuid"
+	"database/sql"
 	"ichat-go/di"
 	"ichat-go/errs"
 	"ichat-go/logic/notification"
+	"ichat-go/model/dao"
 	"ichat-go/model/dto"
 	"ichat-go/model/entity"
+	"ichat-go/utils/strs"
+	"slices"
+	"time"
 )
 
-func verifyGroupMembers(groupId uint64, userIds []uint64) {
-	ids := di.ENV().GroupDao().GetMemberUserIds(groupId)
+// 校验用户都是聊天室成员
+func verifyRoomMembers(roomId uint64, userIds []uint64) {
+	ids := di.ENV().ChatDao().GetRoomMemberUserIds(roomId)
 	idSet := make(map[uint64]bool)
 	for _, id := range ids {
 		idSet[id] = true
@@ -28,8 +32,9 @@ func verifyContacts(myId uint64, contactIds []uint64) []uint64 {
 	for _, contactId := range contactIds {
 		c := di.ENV().ContactDao().FindContactById(contactId)
 		verifyContact(c, myId)
+		checkContactWritable(c)
 		if c.UserId == 0 {
-			panic(errs.NewAppError(errs.CodeBadRequest, "只能给用户联系人创建群组"))
+			panic(errs.NewAppError(errs.CodeBadRequest, "只能选择用户联系人"))
 		}
 		userIds = append(userIds, c.UserId)
 	}
@@ -43,9 +48,8 @@ func GroupCreate(myId uint64, d *dto.CreateGroupDto) {
 	groupDao := di.ENV().GroupDao(tx)
 	contactDao := di.ENV().ContactDao(tx)
 	userIds := verifyContacts(myId, d.ContactIds)
-	/* room 和 group循环依赖，只能先让一个为临时值 */
-	room := &entity.ChatRoom{Name: uuid.New().String()}
-	chatDao.CreateChatRoom(room)
+	room := &entity.ChatRoom{Type: entity.ChatRoomTypeGroup}
+	chatDao.CreateChatRoom(room, userIds)
 	g := &entity.Group{
 		RoomId:  room.RoomId,
 		OwnerId: myId,
@@ -54,8 +58,6 @@ func GroupCreate(myId uint64, d *dto.CreateGroupDto) {
 	}
 	groupDao.CreateGroup(g)
 	groupDao.CreateMember(g, userIds)
-	room.Name = fmt.Sprintf("g-%d", g.GroupId)
-	chatDao.UpdateRoomName(room)
 	var contacts []*entity.Contact
 	for _, userId := range userIds {
 		c := &entity.Contact{
@@ -67,16 +69,278 @@ func GroupCreate(myId uint64, d *dto.CreateGroupDto) {
 		contactDao.CreateContact(c)
 		contacts = append(contacts, c)
 	}
+	m := sendSystemMessage(tx, contacts[0], myId, &entity.SystemMessage{
+		Event: entity.SystemEventGroupCreated,
+		Name:  g.Name,
+	})
+	setContactsLastMessage(contacts, m)
 	tx.Commit()
 	for _, c := range contacts {
+		if c.OwnerId != myId {
+			c.UnreadCount = 1
+		}
+		notification.SendNewContact(c.OwnerId, c)
+	}
+}
+
+func GroupUpdateSettings(myId uint64, d *dto.GroupSettingsDto) {
+	g, _ := checkGroupPermission(myId, d.GroupId, groupActionUpdateSettings)
+	if d.MemberCanPin != nil {
+		di.ENV().GroupDao().UpdateMemberCanPin(d.GroupId, *d.MemberCanPin)
+		g.MemberCanPin = *d.MemberCanPin
+	}
+	notifyGroupUpdated(g)
+}
+
+// 群成员数量上限
+const maxGroupMembers = 500
+
+// 校验是群成员，返回自己的群联系人
+func verifyGroupContact(myId uint64, groupId uint64) *entity.Contact {
+	c := di.ENV().ContactDao().FindGroupContact(myId, groupId)
+	if c == nil {
+		panic(errs.ContactNotFound)
+	}
+	return c
+}
+
+func setContactsLastMessage(contacts []*entity.Contact, m *dto.ChatMessageDto) {
+	for _, c := range contacts {
+		c.LastMessageId = m.MessageId
+		c.LastMessageTime = &m.CreatedAt
+		c.LastMessageContent = describeChatMessage(&m.ChatMessage)
+	}
+}
+
+// GroupAddMembers 群成员邀请自己的用户联系人入群，新成员看不到入群前的聊天记录
+func GroupAddMembers(myId uint64, d *dto.GroupMembersDto) {
+	myContact := verifyGroupContact(myId, d.GroupId)
+	g := di.ENV().GroupDao().FindGroupById(d.GroupId)
+	if g.Dissolved {
+		panic(errs.GroupDissolved)
+	}
+	memberIds := di.ENV().ChatDao().GetRoomMemberUserIds(g.RoomId)
+	var userIds []uint64
+	for _, userId := range verifyContacts(myId, d.ContactIds)[1:] {
+		if !slices.Contains(memberIds, userId) && !slices.Contains(userIds, userId) {
+			userIds = append(userIds, userId)
+		}
+	}
+	if len(userIds) == 0 {
+		return
+	}
+	if len(memberIds)+len(userIds) > maxGroupMembers {
+		panic(errs.GroupMemberLimitExceeded)
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	contacts := addGroupMembers(tx, g, userIds, myContact.MessageTTL)
+	m := sendSystemMessage(tx, myContact, myId, &entity.SystemMessage{
+		Event:   entity.SystemEventMembersAdded,
+		UserIds: userIds,
+	})
+	setContactsLastMessage(contacts, m)
+	tx.Commit()
+	notifyContactsAdded(contacts)
+}
+
+// 创建群成员和对应的群联系人，messageTTL与群内其他联系人保持一致
+// 先锁定群记录再统计成员数，避免并发入群超过成员上限
+func addGroupMembers(tx dao.Tx, g *entity.Group, userIds []uint64, messageTTL int) []*entity.Contact {
+	if locked := di.ENV().GroupDao(tx).LockGroup(g.GroupId); locked == nil || locked.Dissolved {
+		panic(errs.GroupDissolved)
+	}
+	if len(di.ENV().ChatDao(tx).GetRoomMemberUserIds(g.RoomId))+len(userIds) > maxGroupMembers {
+		panic(errs.GroupMemberLimitExceeded)
+	}
+	lastMessageId := di.ENV().ChatDao(tx).FindLastMessageId(g.RoomId)
+	di.ENV().GroupDao(tx).CreateMember(g, userIds)
+	di.ENV().ChatDao(tx).AddRoomMembers(g.RoomId, userIds)
+	contactDao := di.ENV().ContactDao(tx)
+	var contacts []*entity.Contact
+	for _, userId := range userIds {
+		c := &entity.Contact{
+			OwnerId:          userId,
+			GroupId:          g.GroupId,
+			RoomId:           g.RoomId,
+			Status:           entity.ContactStatusNormal,
+			ClearedMessageId: lastMessageId,
+			MessageTTL:       messageTTL,
+		}
+		contactDao.CreateContact(c)
+		contacts = append(contacts, c)
+	}
+	return contacts
+}
+
+func notifyContactsAdded(contacts []*entity.Contact) {
+	for _, c := range contacts {
+		c.UnreadCount = 1
 		notification.SendNewContact(c.OwnerId, c)
 	}
 }
 
+// 删除成员和对应的群联系人，返回被删除的联系人
+func removeGroupMembers(tx dao.Tx, g *entity.Group, userIds []uint64) []*entity.Contact {
+	contactDao := di.ENV().ContactDao(tx)
+	var contacts []*entity.Contact
+	for _, userId := range userIds {
+		if c := contactDao.FindGroupContact(userId, g.GroupId); c != nil {
+			contactDao.DeleteContact(c.ContactId)
+			contacts = append(contacts, c)
+		}
+	}
+	di.ENV().GroupDao(tx).DeleteMembers(g.GroupId, userIds)
+	di.ENV().ChatDao(tx).RemoveRoomMembers(g.RoomId, userIds)
+	return contacts
+}
+
+func notifyContactsRemoved(contacts []*entity.Contact) {
+	for _, c := range contacts {
+		notification.SendContactRemoved(c.OwnerId, &dto.ContactRemovedDto{
+			ContactId: c.ContactId,
+			RoomId:    c.RoomId,
+		})
+	}
+}
+
+// GroupRemoveMembers 群主、管理员移除角色比自己低的成员
+func GroupRemoveMembers(myId uint64, d *dto.RemoveGroupMembersDto) {
+	g, myRole := checkGroupPermission(myId, d.GroupId, groupActionRemoveMember)
+	for _, userId := range d.UserIds {
+		checkGroupTarget(g, myRole, userId)
+	}
+	myContact := verifyGroupContact(myId, d.GroupId)
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	contacts := removeGroupMembers(tx, g, d.UserIds)
+	sendSystemMessage(tx, myContact, myId, &entity.SystemMessage{
+		Event:   entity.SystemEventMembersRemoved,
+		UserIds: d.UserIds,
+	})
+	tx.Commit()
+	notifyContactsRemoved(contacts)
+}
+
+// GroupLeave 退出群聊，群主需要先转让群主；群已解散时只删除自己的群联系人
+func GroupLeave(myId uint64, groupId uint64) {
+	myContact := verifyGroupContact(myId, groupId)
+	g := di.ENV().GroupDao().FindGroupById(groupId)
+	if g.OwnerId == myId && !g.Dissolved {
+		panic(errs.GroupOwnerCannotLeave)
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	contacts := removeGroupMembers(tx, g, []uint64{myId})
+	if !g.Dissolved {
+		sendSystemMessage(tx, myContact, myId, &entity.SystemMessage{
+			Event: entity.SystemEventMemberLeft,
+		})
+	}
+	tx.Commit()
+	notifyContactsRemoved(contacts)
+}
+
+// GroupDissolve 解散群聊，群联系人变为只读，保留聊天记录，结束进行中的通话
+func GroupDissolve(myId uint64, groupId uint64) {
+	g, _ := checkGroupPermission(myId, groupId, groupActionDissolve)
+	myContact := verifyGroupContact(myId, groupId)
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	if !di.ENV().GroupDao(tx).Dissolve(groupId) {
+		panic(errs.GroupDissolved)
+	}
+	sendSystemMessage(tx, myContact, myId, &entity.SystemMessage{
+		Event: entity.SystemEventGroupDissolved,
+	})
+	di.ENV().ContactDao(tx).UpdateStatusByRoomId(g.RoomId, entity.ContactStatusReadOnly)
+	tx.Commit()
+	endRoomCalls(g.RoomId, entity.CallEndReasonClosed)
+	g.Dissolved = true
+	notifyGroupUpdated(g)
+	for _, c := range di.ENV().ContactDao().GetContactsByRoomId(g.RoomId) {
+		notification.SendContactStatus(c.OwnerId, &dto.ContactStatusDto{
+			ContactId: c.ContactId,
+			RoomId:    c.RoomId,
+			Status:    c.Status,
+		})
+	}
+}
+
+// GroupUpdateProfile 修改群名、群头像、群公告，每项修改都会发送系统消息
+func GroupUpdateProfile(myId uint64, d *dto.GroupProfileDto) {
+	g, _ := checkGroupPermission(myId, d.GroupId, groupActionUpdateProfile)
+	myContact := verifyGroupContact(myId, g.GroupId)
+	var events []*entity.SystemMessage
+	var announcement *entity.GroupAnnouncement
+	if d.Name != nil && *d.Name != g.Name {
+		g.Name = *d.Name
+		events = append(events, &entity.SystemMessage{Event: entity.SystemEventGroupRenamed, Name: g.Name})
+	}
+	if d.Avatar != nil && *d.Avatar != g.Avatar {
+		g.Avatar = *d.Avatar
+		events = append(events, &entity.SystemMessage{Event: entity.SystemEventGroupAvatar})
+	}
+	if d.Announcement != nil && *d.Announcement != g.Announcement {
+		now := time.Now()
+		g.Announcement = *d.Announcement
+		g.AnnouncementBy = myId
+		g.AnnouncementAt = &now
+		announcement = &entity.GroupAnnouncement{
+			GroupId: g.GroupId,
+			UserId:  myId,
+			Content: g.Announcement,
+		}
+		events = append(events, &entity.SystemMessage{
+			Event: entity.SystemEventAnnouncement,
+			Text:  strs.TakeFirstN(g.Announcement, 20, true),
+		})
+	}
+	if len(events) == 0 {
+		return
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	groupDao := di.ENV().GroupDao(tx)
+	groupDao.UpdateProfile(g)
+	if announcement != nil {
+		groupDao.CreateAnnouncement(announcement)
+	}
+	for _, e := range events {
+		sendSystemMessage(tx, myContact, myId, e)
+	}
+	tx.Commit()
+	notifyGroupUpdated(g)
+}
+
+// 通知群成员刷新群资料
+func notifyGroupUpdated(g *entity.Group) {
+	for _, userId := range di.ENV().ChatDao().GetRoomMemberUserIds(g.RoomId) {
+		notification.SendGroupUpdated(userId, g)
+	}
+}
+
+func GroupGetAnnouncements(myId uint64, d *dto.QueryAnnouncementsDto) []*entity.GroupAnnouncement {
+	verifyGroupContact(myId, d.GroupId)
+	if d.Limit == 0 {
+		d.Limit = 10
+	}
+	return di.ENV().GroupDao().GetAnnouncements(d.GroupId, d.LastId, d.Limit)
+}
+
 func GroupGetInfos(groupIds []uint64) []*entity.Group {
 	return di.ENV().GroupDao().FindGroups(groupIds)
 }
 
-func getGroupMembers(groupId uint64) []*entity.User {
-	return di.ENV().GroupDao().GetMembers(groupId)
+func getGroupMembers(groupId uint64) []*dao.Member {
+	g := di.ENV().GroupDao().FindGroupById(groupId)
+	members := di.ENV().GroupDao().GetMembers(groupId)
+	for _, m := range members {
+		if m.UserId == g.OwnerId {
+			m.Role = entity.GroupRoleOwner
+		} else if m.Role == entity.GroupRoleOwner {
+			m.Role = entity.GroupRoleMember
+		}
+	}
+	return members
 }
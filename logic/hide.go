@@ -0,0 +1,77 @@
+package logic
+
+import (
+	"database/sql"
+	"ichat-go/di"
+	"ichat-go/errs"
+	"ichat-go/logic/notification"
+	"ichat-go/model/dao"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+	"slices"
+)
+
+// 重新计算联系人的最后一条消息，跳过该用户已删除的消息
+func refreshContactLastMessage(tx dao.Tx, c *entity.Contact) {
+	m := di.ENV().ChatDao(tx).FindLastVisibleMessage(c.OwnerId, c.RoomId)
+	if m == nil {
+		c.LastMessageId = 0
+		c.LastMessageTime = nil
+		c.LastMessageContent = ""
+	} else {
+		c.LastMessageId = m.MessageId
+		c.LastMessageTime = &m.CreatedAt
+		c.LastMessageContent = describeChatMessage(m)
+	}
+	di.ENV().ContactDao(tx).UpdateLastMessage(c)
+}
+
+// ChatHideMessages 删除消息，仅对自己不可见
+func ChatHideMessages(myId uint64, d *dto.HideMessagesDto) {
+	contact := di.ENV().ContactDao().FindContactById(d.ContactId)
+	verifyContact(contact, myId)
+	slices.Sort(d.MessageIds)
+	d.MessageIds = slices.Compact(d.MessageIds)
+	messages := di.ENV().ChatDao().FindMessagesByIds(d.MessageIds)
+	if len(messages) != len(d.MessageIds) {
+		panic(errs.Forbidden)
+	}
+	for _, m := range messages {
+		if m.RoomId != contact.RoomId {
+			panic(errs.Forbidden)
+		}
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	di.ENV().ChatDao(tx).HideMessages(myId, d.MessageIds)
+	refreshContactLastMessage(tx, contact)
+	updateContactReadState(tx, contact, 0) // 删除的未读消息不再计入未读数
+	go notification.SendMessagesHidden(myId, &dto.MessagesHiddenDto{
+		ContactId:  contact.ContactId,
+		MessageIds: d.MessageIds,
+	})
+}
+
+// ChatClearHistory 清空与联系人的聊天记录，仅对自己不可见
+func ChatClearHistory(myId uint64, contactId uint64) {
+	contact := di.ENV().ContactDao().FindContactById(contactId)
+	verifyContact(contact, myId)
+	lastMessageId := di.ENV().ChatDao().FindLastMessageId(contact.RoomId)
+	if lastMessageId <= contact.ClearedMessageId {
+		return
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	clearHistory(tx, contact, lastMessageId)
+}
+
+func clearHistory(tx dao.Tx, contact *entity.Contact, lastMessageId uint64) {
+	contact.ClearedMessageId = lastMessageId
+	di.ENV().ContactDao(tx).UpdateClearedMessageId(contact.ContactId, lastMessageId)
+	refreshContactLastMessage(tx, contact)
+	updateContactReadState(tx, contact, lastMessageId)
+	go notification.SendMessagesHidden(contact.OwnerId, &dto.MessagesHiddenDto{
+		ContactId:        contact.ContactId,
+		ClearedMessageId: lastMessageId,
+	})
+}
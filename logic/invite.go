@@ -0,0 +1,200 @@
+package logic
+
+import (
+	"database/sql"
+	"ichat-go/di"
+	"ichat-go/errs"
+	"ichat-go/logic/notification"
+	"ichat-go/model/dao"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+	"ichat-go/security"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func inviteSignData(inviteId string) string {
+	return "group-invite:" + inviteId
+}
+
+// 邀请码格式为 邀请id.签名，签名防止遍历邀请id
+func inviteCode(invite *entity.GroupInvite) string {
+	id := strconv.FormatUint(invite.Id, 10)
+	return id + "." + security.Sign(inviteSignData(id))
+}
+
+// 解析邀请码并校验邀请链接是否有效，返回邀请和群
+func parseInviteCode(code string) (*entity.GroupInvite, *entity.Group) {
+	id, sign, found := strings.Cut(code, ".")
+	if !found || !security.VerifySign(inviteSignData(id), sign) {
+		panic(errs.GroupInviteInvalid)
+	}
+	inviteId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		panic(errs.GroupInviteInvalid)
+	}
+	invite := di.ENV().GroupInviteDao().FindInviteById(inviteId)
+	if invite == nil || invite.Revoked ||
+		(invite.ExpireAt != nil && time.Now().After(*invite.ExpireAt)) ||
+		(invite.MaxUses != 0 && invite.Uses >= invite.MaxUses) {
+		panic(errs.GroupInviteInvalid)
+	}
+	g := di.ENV().GroupDao().FindGroupById(invite.GroupId)
+	if g == nil || g.Dissolved {
+		panic(errs.GroupInviteInvalid)
+	}
+	return invite, g
+}
+
+func toInviteDto(invite *entity.GroupInvite) *dto.GroupInviteDto {
+	return &dto.GroupInviteDto{GroupInvite: invite, Code: inviteCode(invite)}
+}
+
+func GroupCreateInvite(myId uint64, d *dto.CreateGroupInviteDto) *dto.GroupInviteDto {
+	checkGroupPermission(myId, d.GroupId, groupActionInvite)
+	invite := &entity.GroupInvite{
+		GroupId:         d.GroupId,
+		CreatorId:       myId,
+		MaxUses:         d.MaxUses,
+		RequireApproval: d.RequireApproval,
+	}
+	if d.ExpireIn != 0 {
+		expireAt := time.Now().Add(time.Duration(d.ExpireIn) * time.Second)
+		invite.ExpireAt = &expireAt
+	}
+	di.ENV().GroupInviteDao().CreateInvite(invite)
+	return toInviteDto(invite)
+}
+
+// GroupGetInvites 查询群内仍然有效的邀请链接
+func GroupGetInvites(myId uint64, groupId uint64) []*dto.GroupInviteDto {
+	checkGroupPermission(myId, groupId, groupActionInvite)
+	invites := make([]*dto.GroupInviteDto, 0)
+	for _, invite := range di.ENV().GroupInviteDao().GetActiveInvites(groupId) {
+		invites = append(invites, toInviteDto(invite))
+	}
+	return invites
+}
+
+func GroupRevokeInvite(myId uint64, inviteId uint64) {
+	invite := di.ENV().GroupInviteDao().FindInviteById(inviteId)
+	if invite == nil {
+		panic(errs.GroupInviteInvalid)
+	}
+	checkGroupPermission(myId, invite.GroupId, groupActionInvite)
+	di.ENV().GroupInviteDao().RevokeInvite(inviteId)
+}
+
+// GroupGetInviteInfo 通过邀请码查看群信息，不要求是群成员
+func GroupGetInviteInfo(code string) *dto.GroupInviteInfoDto {
+	invite, g := parseInviteCode(code)
+	return &dto.GroupInviteInfoDto{
+		Group:           g,
+		MemberCount:     len(di.ENV().ChatDao().GetRoomMemberUserIds(g.RoomId)),
+		RequireApproval: invite.RequireApproval,
+	}
+}
+
+// GroupJoinByInvite 通过邀请链接入群，需要审核时提交入群申请并通知群主和管理员，
+// 申请通过时才计入邀请链接的使用次数
+func GroupJoinByInvite(myId uint64, code string) *dto.JoinGroupResultDto {
+	invite, g := parseInviteCode(code)
+	if groupRole(g, myId) != 0 {
+		panic(errs.GroupAlreadyJoined)
+	}
+	if len(di.ENV().ChatDao().GetRoomMemberUserIds(g.RoomId)) >= maxGroupMembers {
+		panic(errs.GroupMemberLimitExceeded)
+	}
+	if invite.RequireApproval {
+		if di.ENV().GroupInviteDao().FindPendingJoinRequest(g.GroupId, myId) != nil {
+			panic(errs.GroupJoinRequestExists)
+		}
+		request := &entity.GroupJoinRequest{
+			GroupId:  g.GroupId,
+			InviteId: invite.Id,
+			UserId:   myId,
+			Status:   entity.GroupJoinRequestStatusPending,
+		}
+		di.ENV().GroupInviteDao().CreateJoinRequest(request)
+		adminIds := di.ENV().GroupDao().GetAdminUserIds(g.GroupId)
+		if !slices.Contains(adminIds, g.OwnerId) {
+			adminIds = append(adminIds, g.OwnerId)
+		}
+		for _, userId := range adminIds {
+			notification.SendGroupJoinRequest(userId, request)
+		}
+		return &dto.JoinGroupResultDto{GroupId: g.GroupId, Pending: true}
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	useInvite(tx, invite.Id)
+	contacts := joinGroup(tx, g, myId)
+	tx.Commit()
+	notifyContactsAdded(contacts)
+	return &dto.JoinGroupResultDto{GroupId: g.GroupId}
+}
+
+// 邀请链接使用次数加一，链接已失效或次数已用完时失败
+func useInvite(tx dao.Tx, inviteId uint64) {
+	if !di.ENV().GroupInviteDao(tx).UseInvite(inviteId) {
+		panic(errs.GroupInviteInvalid)
+	}
+}
+
+// 用户加入群聊并发送入群系统消息
+func joinGroup(tx dao.Tx, g *entity.Group, userId uint64) []*entity.Contact {
+	ownerContact := di.ENV().ContactDao(tx).FindGroupContact(g.OwnerId, g.GroupId)
+	contacts := addGroupMembers(tx, g, []uint64{userId}, ownerContact.MessageTTL)
+	m := sendSystemMessage(tx, contacts[0], userId, &entity.SystemMessage{
+		Event: entity.SystemEventMemberJoined,
+	})
+	setContactsLastMessage(contacts, m)
+	return contacts
+}
+
+func GroupGetJoinRequests(myId uint64, groupId uint64) []*entity.GroupJoinRequest {
+	checkGroupPermission(myId, groupId, groupActionInvite)
+	return di.ENV().GroupInviteDao().GetPendingJoinRequests(groupId)
+}
+
+func findPendingJoinRequest(myId uint64, requestId uint64) (*entity.GroupJoinRequest, *entity.Group) {
+	request := di.ENV().GroupInviteDao().FindJoinRequestById(requestId)
+	if request == nil || request.Status != entity.GroupJoinRequestStatusPending {
+		panic(errs.GroupJoinRequestNotPending)
+	}
+	g, _ := checkGroupPermission(myId, request.GroupId, groupActionInvite)
+	return request, g
+}
+
+// GroupAcceptJoinRequest 同意入群申请并计入邀请链接的使用次数，申请人已通过其他方式入群时只更新申请状态。
+// 申请是在邀请链接有效时提交的，之后链接过期、撤销或次数用完都不影响审核通过
+func GroupAcceptJoinRequest(myId uint64, requestId uint64) {
+	request, g := findPendingJoinRequest(myId, requestId)
+	joined := groupRole(g, request.UserId) != 0
+	if !joined && len(di.ENV().ChatDao().GetRoomMemberUserIds(g.RoomId)) >= maxGroupMembers {
+		panic(errs.GroupMemberLimitExceeded)
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	ok := di.ENV().GroupInviteDao(tx).UpdateJoinRequestStatus(requestId, myId, entity.GroupJoinRequestStatusAccepted)
+	if !ok {
+		panic(errs.GroupJoinRequestNotPending)
+	}
+	var contacts []*entity.Contact
+	if !joined {
+		di.ENV().GroupInviteDao(tx).IncreaseInviteUses(request.InviteId)
+		contacts = joinGroup(tx, g, request.UserId)
+	}
+	tx.Commit()
+	notifyContactsAdded(contacts)
+}
+
+func GroupRejectJoinRequest(myId uint64, requestId uint64) {
+	findPendingJoinRequest(myId, requestId)
+	ok := di.ENV().GroupInviteDao().UpdateJoinRequestStatus(requestId, myId, entity.GroupJoinRequestStatusRejected)
+	if !ok {
+		panic(errs.GroupJoinRequestNotPending)
+	}
+}
@@ -0,0 +1,121 @@
+package logic
+
+import (
+	"database/sql"
+	"ichat-go/di"
+	"ichat-go/errs"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+	"time"
+)
+
+// 校验群成员没有被禁言，全员禁言时群主和管理员仍可发言
+func checkGroupMute(contact *entity.Contact, userId uint64) {
+	if contact.GroupId == 0 {
+		return
+	}
+	g := di.ENV().GroupDao().FindGroupById(contact.GroupId)
+	m := di.ENV().GroupDao().FindMember(contact.GroupId, userId)
+	if g == nil || m == nil {
+		panic(errs.Forbidden)
+	}
+	if m.MutedUntil != nil && time.Now().Before(*m.MutedUntil) {
+		panic(errs.GroupMemberMuted)
+	}
+	if g.MuteAll && groupRole(g, userId) > entity.GroupRoleAdmin {
+		panic(errs.GroupMuted)
+	}
+}
+
+func GroupMuteAll(myId uint64, d *dto.MuteAllDto) {
+	g, _ := checkGroupPermission(myId, d.GroupId, groupActionMute)
+	if g.MuteAll == d.Mute {
+		return
+	}
+	myContact := verifyGroupContact(myId, d.GroupId)
+	event := entity.SystemEventMuteAllDisabled
+	if d.Mute {
+		event = entity.SystemEventMuteAllEnabled
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	di.ENV().GroupDao(tx).UpdateMuteAll(d.GroupId, d.Mute)
+	sendSystemMessage(tx, myContact, myId, &entity.SystemMessage{Event: event})
+	tx.Commit()
+	g.MuteAll = d.Mute
+	notifyGroupUpdated(g)
+}
+
+// GroupMuteMember 禁言角色比自己低的成员，到期后通过延迟任务自动解除
+func GroupMuteMember(myId uint64, d *dto.MuteMemberDto) {
+	g, myRole := checkGroupPermission(myId, d.GroupId, groupActionMute)
+	checkGroupTarget(g, myRole, d.UserId)
+	m := di.ENV().GroupDao().FindMember(d.GroupId, d.UserId)
+	muted := m.MutedUntil != nil && time.Now().Before(*m.MutedUntil)
+	if d.Duration == 0 && !muted {
+		return
+	}
+	myContact := verifyGroupContact(myId, d.GroupId)
+	s := &entity.SystemMessage{
+		Event:   entity.SystemEventMemberUnmuted,
+		UserIds: []uint64{d.UserId},
+	}
+	var mutedUntil *time.Time
+	if d.Duration != 0 {
+		// 数据库时间精度为秒，到期解除时需要按原值比较
+		t := time.Now().Add(time.Duration(d.Duration) * time.Second).Truncate(time.Second)
+		mutedUntil = &t
+		s.Event = entity.SystemEventMemberMuted
+		s.Duration = d.Duration
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	di.ENV().GroupDao(tx).UpdateMemberMutedUntil(d.GroupId, d.UserId, mutedUntil)
+	sendSystemMessage(tx, myContact, myId, s)
+	tx.Commit()
+	if mutedUntil != nil {
+		scheduleTask(taskTypeMemberUnmute, m.Id, *mutedUntil)
+	} else {
+		cancelTask(taskTypeMemberUnmute, m.Id)
+	}
+}
+
+// 禁言到期自动解除，禁言时间被修改过时重新调度
+func unmuteMember(id uint64) {
+	m := di.ENV().GroupDao().FindMemberById(id)
+	if m == nil || m.MutedUntil == nil {
+		return
+	}
+	if time.Until(*m.MutedUntil) > time.Second {
+		scheduleTask(taskTypeMemberUnmute, m.Id, *m.MutedUntil)
+		return
+	}
+	g := di.ENV().GroupDao().FindGroupById(m.GroupId)
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	if !di.ENV().GroupDao(tx).ClearExpiredMute(m.Id, *m.MutedUntil) || g.Dissolved {
+		return
+	}
+	if c := di.ENV().ContactDao(tx).FindGroupContact(m.UserId, m.GroupId); c != nil {
+		sendSystemMessage(tx, c, 0, &entity.SystemMessage{
+			Event:   entity.SystemEventMemberUnmuted,
+			UserIds: []uint64{m.UserId},
+		})
+	}
+}
+
+// 启动时重新调度禁言到期任务
+func rescheduleMemberUnmutes() {
+	defer func() {
+		if err := recover(); err != nil {
+			taskLogger.Error("reschedule member unmutes failed: ", err)
+		}
+	}()
+	for _, m := range di.ENV().GroupDao().GetMutedMembers() {
+		scheduleTask(taskTypeMemberUnmute, m.Id, *m.MutedUntil)
+	}
+}
+
+func init() {
+	taskHandlers[taskTypeMemberUnmute] = unmuteMember
+}
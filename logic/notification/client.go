@@ -0,0 +1,44 @@
+package notification
+
+import (
+	"encoding/json"
+	"ichat-go/model/dto"
+)
+
+// Callbacks 客户端消息的处理回调，由业务逻辑层注入，避免循环依赖
+type Callbacks struct {
+	AckReceived func(userId uint64, d *dto.AckReceivedDto)
+	MarkRead    func(userId uint64, d *dto.MarkReadDto)
+	Typing      func(userId uint64, d *dto.TypingDto)
+}
+
+var callbacks Callbacks
+
+func SetCallbacks(c Callbacks) {
+	callbacks = c
+}
+
+func decodePayload[T any](userId uint64, m *clientMessage, handler func(userId uint64, d *T)) {
+	var d T
+	if err := json.Unmarshal(m.Payload, &d); err != nil {
+		sessionLogger.Warn("Invalid client message payload: ", m.Type)
+		return
+	}
+	handler(userId, &d)
+}
+
+func handleClientMessage(userId uint64, m *clientMessage) {
+	switch m.Type {
+	case clientTypeAckReceived:
+		decodePayload(userId, m, callbacks.AckReceived)
+	case clientTypeMarkRead:
+		decodePayload(userId, m, callbacks.MarkRead)
+	case clientTypeTyping, clientTypeStopTyping:
+		decodePayload(userId, m, func(userId uint64, d *dto.TypingDto) {
+			d.Typing = m.Type == clientTypeTyping
+			callbacks.Typing(userId, d)
+		})
+	default:
+		sessionLogger.Warn("Unknown client message type: ", m.Type)
+	}
+}
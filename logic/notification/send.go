@@ -1,6 +1,7 @@
 package notification
 
 import (
+	"ichat-go/di"
 	"ichat-go/model/dto"
 	"ichat-go/model/entity"
 )
@@ -11,6 +12,14 @@ func send(userId uint64, n Notification) {
 	}
 }
 
+// 临时通知只发布给在线会话，不写入会话消息队列，断线重连后不会补发
+func sendEphemeral(userId uint64, n Notification) {
+	c := di.ENV().RDB()
+	if err := c.Publish(c.Context(), ephemeralChannel(userId), n.toJson()).Err(); err != nil {
+		sessionLogger.Error("Failed to send ephemeral notification:", err)
+	}
+}
+
 func SendChatMessage(userId uint64, m *dto.ChatMessageDto, new bool) {
 	n := &dto.NotificationMessageDto{
 		ChatMessageDto: *m,
@@ -19,6 +28,23 @@ func SendChatMessage(userId uint64, m *dto.ChatMessageDto, new bool) {
 	send(userId, newChatMessage(n))
 }
 
+// SendChatMessages 批量发送消息通知，ms的key为接收者用户id
+func SendChatMessages(ms map[uint64]*dto.ChatMessageDto, new bool) {
+	userIds := make([]uint64, 0, len(ms))
+	for userId := range ms {
+		userIds = append(userIds, userId)
+	}
+	for userId, sessions := range findUsersSessions(userIds) {
+		n := newChatMessage(&dto.NotificationMessageDto{
+			ChatMessageDto: *ms[userId],
+			IsNew:          new,
+		})
+		for _, session := range sessions {
+			session.Send(n)
+		}
+	}
+}
+
 func SendNewContact(userId uint64, c *dto.ContactDto) {
 	send(userId, newContact(c))
 }
@@ -30,3 +56,47 @@ func SendNewContactRequest(userId uint64, r *entity.ContactRequest) {
 func SendCallHandled(userId uint64, callId uint64) {
 	send(userId, callHandled(callId))
 }
+
+func SendMessageReaction(userId uint64, r *dto.MessageReactionDto) {
+	send(userId, messageReaction(r))
+}
+
+func SendMessageReceipt(userId uint64, r *dto.ReceiptDto) {
+	send(userId, messageReceipt(r))
+}
+
+func SendContactUnread(userId uint64, u *dto.ContactUnreadDto) {
+	send(userId, contactUnread(u))
+}
+
+func SendTyping(userId uint64, t *dto.TypingNotificationDto) {
+	sendEphemeral(userId, typing(t))
+}
+
+func SendMessagesHidden(userId uint64, h *dto.MessagesHiddenDto) {
+	send(userId, messagesHidden(h))
+}
+
+func SendPinsUpdated(userId uint64, p *dto.PinsUpdatedDto) {
+	send(userId, pinsUpdated(p))
+}
+
+func SendContactRemoved(userId uint64, c *dto.ContactRemovedDto) {
+	send(userId, contactRemoved(c))
+}
+
+func SendGroupUpdated(userId uint64, g *entity.Group) {
+	send(userId, groupUpdated(g))
+}
+
+func SendGroupJoinRequest(userId uint64, r *entity.GroupJoinRequest) {
+	send(userId, groupJoinRequest(r))
+}
+
+func SendContactStatus(userId uint64, c *dto.ContactStatusDto) {
+	send(userId, contactStatus(c))
+}
+
+func SendReplyUpdated(userId uint64, r *dto.ReplyUpdatedDto) {
+	send(userId, replyUpdated(r))
+}
@@ -30,6 +30,11 @@ func sessionKey(userId uint64, sessionId string) string {
 	return fmt.Sprintf("noti:%d:%s", userId, sessionId)
 }
 
+// 临时通知的频道，用户所有在线会话订阅该频道，离线期间的临时通知直接丢弃
+func ephemeralChannel(userId uint64) string {
+	return fmt.Sprintf("notification:ephemeral:%d", userId)
+}
+
 func newSessionId() string {
 	return uuid.NewString()
 }
@@ -45,12 +50,6 @@ func sessionHeartbeat(userId uint64, sessionId string) {
 	c.ZAdd(c.Context(), userSessionsKey(userId), &redis.Z{Score: float64(time.Now().Add(userSessionTTL).Unix()), Member: sessionId})
 }
 
-func findSessionIds(userId uint64) []string {
-	c := di.ENV().RDB()
-	keys := c.ZRange(c.Context(), userSessionsKey(userId), 0, -1).Val()
-	return keys
-}
-
 type mqSession struct {
 	mq sched.MQ
 }
@@ -58,18 +57,39 @@ type mqSession struct {
 var sessionLogger = logging.NewLogger("notification")
 
 func (s *mqSession) Send(n Notification) {
-	err := s.mq.PushIfStateExits(sched.Message{Payload: n.toJson()})
+	err := s.mq.PushIfStateExits(sched.Message{Type: n.Type, Payload: n.toJson()})
 	if err != nil {
 		sessionLogger.Error("Failed to send notification:", err)
 	}
 }
 
 func findSessions(userId uint64) []Session {
-	var sessions []Session
-	for _, sessionId := range findSessionIds(userId) {
-		mq := sched.NewMQ(sessionKey(userId, sessionId))
-		if mq.State() != 0 {
-			sessions = append(sessions, &mqSession{mq: mq})
+	return findUsersSessions([]uint64{userId})[userId]
+}
+
+// 批量查询多个用户的会话，会话id和会话状态分别用一次pipeline查询，避免大群通知时逐个用户往返
+func findUsersSessions(userIds []uint64) map[uint64][]Session {
+	c := di.ENV().RDB()
+	pipe := c.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(userIds))
+	for i, userId := range userIds {
+		cmds[i] = pipe.ZRange(c.Context(), userSessionsKey(userId), 0, -1)
+	}
+	if len(cmds) > 0 {
+		_, _ = pipe.Exec(c.Context())
+	}
+	var keys []string
+	var owners []uint64
+	for i, cmd := range cmds {
+		for _, sessionId := range cmd.Val() {
+			keys = append(keys, sessionKey(userIds[i], sessionId))
+			owners = append(owners, userIds[i])
+		}
+	}
+	sessions := make(map[uint64][]Session)
+	for i, state := range sched.States(keys) {
+		if state != 0 {
+			sessions[owners[i]] = append(sessions[owners[i]], &mqSession{mq: sched.NewMQ(keys[i])})
 		}
 	}
 	return sessions
@@ -11,6 +11,25 @@ const (
 	typeNewContact        = 2
 	typeNewContactRequest = 3
 	typeCallHandled       = 4
+	typeMessageReaction   = 5
+	typeMessageReceipt    = 6
+	typeContactUnread     = 7
+	typeTyping            = 8
+	typeMessagesHidden    = 9
+	typePinsUpdated       = 10
+	typeContactRemoved    = 11
+	typeGroupUpdated      = 12
+	typeGroupJoinRequest  = 13
+	typeContactStatus     = 14
+	typeReplyUpdated      = 15
+)
+
+// 客户端通过实时通知会话发送的消息类型
+const (
+	clientTypeAckReceived = 1
+	clientTypeMarkRead    = 2
+	clientTypeTyping      = 3
+	clientTypeStopTyping  = 4
 )
 
 type Notification struct {
@@ -18,6 +37,11 @@ type Notification struct {
 	Payload any `json:"payload"`
 }
 
+type clientMessage struct {
+	Type    int             `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
 func (n *Notification) toJson() []byte {
 	b, _ := json.Marshal(n)
 	return b
@@ -38,3 +62,47 @@ func newContactRequest(r *entity.ContactRequest) Notification {
 func callHandled(callId uint64) Notification {
 	return Notification{Type: typeCallHandled, Payload: callId}
 }
+
+func messageReaction(r *dto.MessageReactionDto) Notification {
+	return Notification{Type: typeMessageReaction, Payload: r}
+}
+
+func messageReceipt(r *dto.ReceiptDto) Notification {
+	return Notification{Type: typeMessageReceipt, Payload: r}
+}
+
+func contactUnread(u *dto.ContactUnreadDto) Notification {
+	return Notification{Type: typeContactUnread, Payload: u}
+}
+
+func typing(t *dto.TypingNotificationDto) Notification {
+	return Notification{Type: typeTyping, Payload: t}
+}
+
+func messagesHidden(h *dto.MessagesHiddenDto) Notification {
+	return Notification{Type: typeMessagesHidden, Payload: h}
+}
+
+func pinsUpdated(p *dto.PinsUpdatedDto) Notification {
+	return Notification{Type: typePinsUpdated, Payload: p}
+}
+
+func contactRemoved(c *dto.ContactRemovedDto) Notification {
+	return Notification{Type: typeContactRemoved, Payload: c}
+}
+
+func groupUpdated(g *entity.Group) Notification {
+	return Notification{Type: typeGroupUpdated, Payload: g}
+}
+
+func groupJoinRequest(r *entity.GroupJoinRequest) Notification {
+	return Notification{Type: typeGroupJoinRequest, Payload: r}
+}
+
+func contactStatus(c *dto.ContactStatusDto) Notification {
+	return Notification{Type: typeContactStatus, Payload: c}
+}
+
+func replyUpdated(r *dto.ReplyUpdatedDto) Notification {
+	return Notification{Type: typeReplyUpdated, Payload: r}
+}
@@ -2,6 +2,7 @@ package notification
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -133,12 +134,17 @@ func (s *wsSession) loop() {
 	}
 	tick := time.NewTicker(userSessionTTL / 2)
 	defer tick.Stop()
-	go s.ignoreRead()
+	rdb := di.ENV().RDB()
+	ephemeral := rdb.Subscribe(s.ctx, ephemeralChannel(s.userId))
+	defer func() { _ = ephemeral.Close() }()
+	go s.handleRead()
 	for {
 		select {
 		case m := <-s.mq.Channel():
 			s.send(m.Payload)
 			s.mq.Ack(true)
+		case m := <-ephemeral.Channel():
+			s.send([]byte(m.Payload))
 		case <-s.ctx.Done():
 			return
 		case <-tick.C:
@@ -170,12 +176,30 @@ func (s *wsSession) read() <-chan string {
 	return ch
 }
 
-func (s *wsSession) ignoreRead() {
+func (s *wsSession) handleRead() {
 	for {
 		select {
-		case <-s.recv:
+		case data, ok := <-s.recv:
+			if !ok {
+				return
+			}
+			s.handleClientMessage(data)
 		case <-s.ctx.Done():
 			return
 		}
 	}
 }
+
+func (s *wsSession) handleClientMessage(data string) {
+	defer func() {
+		if err := recover(); err != nil {
+			s.logger.Warn("Failed to handle client message: ", err)
+		}
+	}()
+	var m clientMessage
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		// 心跳等非JSON消息直接忽略
+		return
+	}
+	handleClientMessage(s.userId, &m)
+}
@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"database/sql"
+	"ichat-go/di"
+	"ichat-go/errs"
+	"ichat-go/logic/notification"
+	"ichat-go/model/dao"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+)
+
+// 每个聊天室最多置顶的消息数
+const maxPinnedMessages = 10
+
+// 群聊默认只有群主、管理员可以置顶，群设置允许时所有成员都可以置顶
+func checkPinPermission(myId uint64, contact *entity.Contact) {
+	if contact.GroupId != 0 {
+		checkGroupPermission(myId, contact.GroupId, groupActionPin)
+	}
+}
+
+func findPinContact(myId uint64, m *entity.ChatMessage) *entity.Contact {
+	if m == nil {
+		panic(errs.Forbidden)
+	}
+	contact := di.ENV().ContactDao().FindContactByRoomId(myId, m.RoomId)
+	if contact == nil {
+		panic(errs.Forbidden)
+	}
+	checkContactWritable(contact)
+	checkPinPermission(myId, contact)
+	return contact
+}
+
+func getPins(tx dao.Tx, roomId uint64) []*dto.PinnedMessageDto {
+	chatDao := di.ENV().ChatDao(tx)
+	pins := chatDao.GetPins(roomId)
+	results := make([]*dto.PinnedMessageDto, 0, len(pins))
+	if len(pins) == 0 {
+		return results
+	}
+	ids := make([]uint64, 0, len(pins))
+	for _, p := range pins {
+		ids = append(ids, p.MessageId)
+	}
+	messages := make(map[uint64]*dto.ChatMessageDto)
+	for _, m := range messagesToDtos(chatDao.FindMessagesByIds(ids), false) {
+		messages[m.MessageId] = m
+	}
+	for _, p := range pins {
+		d := &dto.PinnedMessageDto{PinnedMessage: *p}
+		if m := messages[p.MessageId]; m != nil {
+			d.Message = m
+		}
+		results = append(results, d)
+	}
+	return results
+}
+
+func notifyPinsUpdated(tx dao.Tx, contact *entity.Contact) {
+	ctx := deliverCtx{tx: tx, contact: contact}
+	userIds := ctx.findUserIds()
+	p := &dto.PinsUpdatedDto{
+		RoomId: contact.RoomId,
+		Pins:   getPins(tx, contact.RoomId),
+	}
+	go func() {
+		for _, uid := range userIds {
+			notification.SendPinsUpdated(uid, p)
+		}
+	}()
+}
+
+func ChatPinMessage(myId uint64, messageId uint64) {
+	m := di.ENV().ChatDao().FindMessageById(messageId)
+	contact := findPinContact(myId, m)
+	if m.Revoked || m.Type == entity.ChatMessageTypeSystem {
+		panic(errs.Forbidden)
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	chatDao := di.ENV().ChatDao(tx)
+	chatDao.LockRoom(m.RoomId) // 锁定聊天室后再统计置顶数，避免并发置顶超过上限
+	if chatDao.FindPin(m.RoomId, m.MessageId) != nil {
+		return
+	}
+	if len(chatDao.GetPins(m.RoomId)) >= maxPinnedMessages {
+		panic(errs.PinLimitExceeded)
+	}
+	chatDao.CreatePin(&entity.PinnedMessage{
+		RoomId:    m.RoomId,
+		MessageId: m.MessageId,
+		UserId:    myId,
+	})
+	sendSystemMessage(tx, contact, myId, &entity.SystemMessage{
+		Event:     entity.SystemEventMessagePinned,
+		MessageId: m.MessageId,
+	})
+	notifyPinsUpdated(tx, contact)
+}
+
+func ChatUnpinMessage(myId uint64, messageId uint64) {
+	m := di.ENV().ChatDao().FindMessageById(messageId)
+	contact := findPinContact(myId, m)
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	if !di.ENV().ChatDao(tx).DeletePin(m.RoomId, m.MessageId) {
+		return
+	}
+	sendSystemMessage(tx, contact, myId, &entity.SystemMessage{
+		Event:     entity.SystemEventMessageUnpinned,
+		MessageId: m.MessageId,
+	})
+	notifyPinsUpdated(tx, contact)
+}
+
+func ChatGetPins(myId uint64, contactId uint64) []*dto.PinnedMessageDto {
+	contact := di.ENV().ContactDao().FindContactById(contactId)
+	verifyContact(contact, myId)
+	return getPins(nil, contact.RoomId)
+}
@@ -0,0 +1,97 @@
+package logic
+
+import (
+	"ichat-go/di"
+	"ichat-go/errs"
+	"ichat-go/logic/notification"
+	"ichat-go/model/dao"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+)
+
+// 查找可以回应的消息，群已解散或已删除好友时不能再修改回应
+func findReactMessage(myId uint64, messageId uint64) *entity.ChatMessage {
+	m := di.ENV().ChatDao().FindMessageById(messageId)
+	if m == nil {
+		panic(errs.Forbidden)
+	}
+	contact := di.ENV().ContactDao().FindContactByRoomId(myId, m.RoomId)
+	if contact == nil {
+		panic(errs.Forbidden)
+	}
+	checkContactWritable(contact)
+	return m
+}
+
+func ChatAddReaction(myId uint64, d *dto.ReactMessageDto) {
+	m := findReactMessage(myId, d.MessageId)
+	if m.Revoked {
+		panic(errs.Forbidden)
+	}
+	di.ENV().ChatDao().SaveReaction(&entity.MessageReaction{
+		MessageId: m.MessageId,
+		UserId:    myId,
+		Emoji:     d.Emoji,
+	})
+	notifyReactionUpdated(myId, m, d.Emoji)
+}
+
+func ChatRemoveReaction(myId uint64, messageId uint64) {
+	m := findReactMessage(myId, messageId)
+	if di.ENV().ChatDao().DeleteReaction(messageId, myId) {
+		notifyReactionUpdated(myId, m, "")
+	}
+}
+
+func ChatGetReactions(myId uint64, messageId uint64) []*dto.ReactionDto {
+	findVisibleMessage(myId, messageId)
+	results := make([]*dto.ReactionDto, 0)
+	m := make(map[string]*dto.ReactionDto)
+	for _, r := range di.ENV().ChatDao().GetReactions(messageId) {
+		item, ok := m[r.Emoji]
+		if !ok {
+			item = &dto.ReactionDto{ReactionCountDto: dto.ReactionCountDto{Emoji: r.Emoji}}
+			m[r.Emoji] = item
+			results = append(results, item)
+		}
+		item.Count++
+		item.UserIds = append(item.UserIds, r.UserId)
+	}
+	return results
+}
+
+func groupReactionCounts(counts []*dao.ReactionCount) map[uint64][]*dto.ReactionCountDto {
+	m := make(map[uint64][]*dto.ReactionCountDto)
+	for _, c := range counts {
+		m[c.MessageId] = append(m[c.MessageId], &dto.ReactionCountDto{Emoji: c.Emoji, Count: c.Count})
+	}
+	return m
+}
+
+func fillReactionCounts(list []*dto.ChatMessageDto) {
+	ids := make([]uint64, 0, len(list))
+	for _, d := range list {
+		ids = append(ids, d.MessageId)
+	}
+	m := groupReactionCounts(di.ENV().ChatDao().CountReactions(ids))
+	for _, d := range list {
+		d.Reactions = m[d.MessageId]
+	}
+}
+
+func notifyReactionUpdated(myId uint64, m *entity.ChatMessage, emoji string) {
+	counts := groupReactionCounts(di.ENV().ChatDao().CountReactions([]uint64{m.MessageId}))
+	r := &dto.MessageReactionDto{
+		RoomId:    m.RoomId,
+		MessageId: m.MessageId,
+		UserId:    myId,
+		Emoji:     emoji,
+		Reactions: counts[m.MessageId],
+	}
+	userIds := di.ENV().ChatDao().GetRoomMemberUserIds(m.RoomId)
+	go func() {
+		for _, userId := range userIds {
+			notification.SendMessageReaction(userId, r)
+		}
+	}()
+}
@@ -0,0 +1,98 @@
+package logic
+
+import (
+	"database/sql"
+	"ichat-go/di"
+	"ichat-go/errs"
+	"ichat-go/logic/notification"
+	"ichat-go/model/dao"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+)
+
+func updateDeliveryStatus(tx dao.Tx, myId uint64, scope dao.DeliveryScope, status int) {
+	receipts := di.ENV().ChatDao(tx).UpdateDeliveryStatus(myId, scope, status)
+	go func() {
+		for _, r := range receipts {
+			notification.SendMessageReceipt(r.SenderId, &dto.ReceiptDto{
+				RoomId:    r.RoomId,
+				UserId:    myId,
+				MessageId: r.MessageId,
+				Status:    status,
+			})
+		}
+	}()
+}
+
+func ChatAckReceived(myId uint64, d *dto.AckReceivedDto) {
+	if d.LastDeliveryId == 0 {
+		return
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	scope := dao.DeliveryScope{LastDeliveryId: d.LastDeliveryId}
+	updateDeliveryStatus(tx, myId, scope, entity.MessageDeliveryStatusReceived)
+}
+
+func ChatMarkRead(myId uint64, d *dto.MarkReadDto) {
+	contact := di.ENV().ContactDao().FindContactById(d.ContactId)
+	verifyContact(contact, myId)
+	if d.MessageId == 0 {
+		d.MessageId = contact.LastMessageId
+	}
+	if d.MessageId == 0 {
+		return
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	scope := dao.DeliveryScope{RoomId: contact.RoomId, LastMessageId: d.MessageId}
+	updateDeliveryStatus(tx, myId, scope, entity.MessageDeliveryStatusRead)
+	updateContactReadState(tx, contact, d.MessageId)
+}
+
+// 更新联系人的已读位置，未读数按已读位置之后他人发送的消息重新计算，@我的未读数按投递记录重新计算
+// 已读位置不超过聊天室最新的消息，避免客户端传入过大的消息id导致之后的消息都被视为已读
+func updateContactReadState(tx dao.Tx, c *entity.Contact, messageId uint64) {
+	chatDao := di.ENV().ChatDao(tx)
+	messageId = min(messageId, chatDao.FindLastMessageId(c.RoomId))
+	if messageId > c.LastReadMessageId {
+		c.LastReadMessageId = messageId
+	}
+	c.UnreadCount = chatDao.CountMessagesAfter(c.OwnerId, c.RoomId, c.LastReadMessageId)
+	c.MentionCount = chatDao.CountUnreadMentions(c.OwnerId, c.RoomId)
+	di.ENV().ContactDao(tx).UpdateReadState(c)
+	u := &dto.ContactUnreadDto{
+		ContactId:         c.ContactId,
+		LastReadMessageId: c.LastReadMessageId,
+		UnreadCount:       c.UnreadCount,
+		MentionCount:      c.MentionCount,
+	}
+	go notification.SendContactUnread(c.OwnerId, u)
+}
+
+// ChatMarkPlayed 标记语音消息已播放
+func ChatMarkPlayed(myId uint64, messageId uint64) {
+	m := findVisibleMessage(myId, messageId)
+	if m.Type != entity.ChatMessageTypeAudio || m.SenderId == myId || m.Revoked {
+		panic(errs.Forbidden)
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	scope := dao.DeliveryScope{MessageId: m.MessageId}
+	updateDeliveryStatus(tx, myId, scope, entity.MessageDeliveryStatusPlayed)
+}
+
+// ChatGetReceipts 消息发送者查询各成员的接收、已读状态
+func ChatGetReceipts(myId uint64, messageId uint64) []*dao.MessageReceipt {
+	m := findVisibleMessage(myId, messageId)
+	if m.SenderId != myId {
+		panic(errs.Forbidden)
+	}
+	results := make([]*dao.MessageReceipt, 0)
+	for _, r := range di.ENV().ChatDao().GetMessageReceipts(messageId) {
+		if r.UserId != myId {
+			results = append(results, r)
+		}
+	}
+	return results
+}
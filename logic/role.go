@@ -0,0 +1,126 @@
+package logic
+
+import (
+	"database/sql"
+	"ichat-go/di"
+	"ichat-go/errs"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+)
+
+type groupAction int
+
+const (
+	groupActionUpdateProfile  groupAction = iota + 1 // 修改群名、群头像、群公告
+	groupActionUpdateSettings                        // 修改群设置
+	groupActionRemoveMember
+	groupActionPin
+	groupActionMute
+	groupActionSetMessageTTL
+	groupActionMentionAll
+	groupActionManageAdmin
+	groupActionTransferOwner
+	groupActionInvite // 管理邀请链接、审核入群申请
+	groupActionDissolve
+)
+
+// 各操作需要的最低角色，角色值越小权限越高
+var groupActionRoles = map[groupAction]int{
+	groupActionUpdateProfile:  entity.GroupRoleAdmin,
+	groupActionUpdateSettings: entity.GroupRoleOwner,
+	groupActionRemoveMember:   entity.GroupRoleAdmin,
+	groupActionPin:            entity.GroupRoleAdmin,
+	groupActionMute:           entity.GroupRoleAdmin,
+	groupActionSetMessageTTL:  entity.GroupRoleAdmin,
+	groupActionMentionAll:     entity.GroupRoleOwner, // @所有人仅限群主
+	groupActionManageAdmin:    entity.GroupRoleOwner,
+	groupActionTransferOwner:  entity.GroupRoleOwner,
+	groupActionInvite:         entity.GroupRoleAdmin,
+	groupActionDissolve:       entity.GroupRoleOwner,
+}
+
+// 用户在群中的角色，不是群成员时为0，群主以Group.OwnerId为准
+func groupRole(g *entity.Group, userId uint64) int {
+	if g.OwnerId == userId {
+		return entity.GroupRoleOwner
+	}
+	m := di.ENV().GroupDao().FindMember(g.GroupId, userId)
+	if m == nil {
+		return 0
+	}
+	return m.Role
+}
+
+// checkGroupPermission 群操作的统一权限校验，返回群和操作者的角色
+func checkGroupPermission(myId uint64, groupId uint64, action groupAction) (*entity.Group, int) {
+	g := di.ENV().GroupDao().FindGroupById(groupId)
+	if g == nil {
+		panic(errs.Forbidden)
+	}
+	if g.Dissolved {
+		panic(errs.GroupDissolved)
+	}
+	role := groupRole(g, myId)
+	required := groupActionRoles[action]
+	if action == groupActionPin && g.MemberCanPin {
+		required = entity.GroupRoleMember
+	}
+	if role == 0 || role > required {
+		panic(errs.Forbidden)
+	}
+	return g, role
+}
+
+// 只能操作角色比自己低的成员
+func checkGroupTarget(g *entity.Group, myRole int, userId uint64) int {
+	role := groupRole(g, userId)
+	if role == 0 || role <= myRole {
+		panic(errs.Forbidden)
+	}
+	return role
+}
+
+func updateGroupRole(myId uint64, d *dto.GroupMemberDto, role int, event int) {
+	g, myRole := checkGroupPermission(myId, d.GroupId, groupActionManageAdmin)
+	if checkGroupTarget(g, myRole, d.UserId) == role {
+		return
+	}
+	myContact := verifyGroupContact(myId, d.GroupId)
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	di.ENV().GroupDao(tx).UpdateMemberRole(d.GroupId, d.UserId, role)
+	sendSystemMessage(tx, myContact, myId, &entity.SystemMessage{
+		Event:   event,
+		UserIds: []uint64{d.UserId},
+	})
+	tx.Commit()
+	notifyGroupUpdated(g)
+}
+
+func GroupAddAdmin(myId uint64, d *dto.GroupMemberDto) {
+	updateGroupRole(myId, d, entity.GroupRoleAdmin, entity.SystemEventAdminAdded)
+}
+
+func GroupRemoveAdmin(myId uint64, d *dto.GroupMemberDto) {
+	updateGroupRole(myId, d, entity.GroupRoleMember, entity.SystemEventAdminRemoved)
+}
+
+// GroupTransferOwner 转让群主，原群主成为普通成员
+func GroupTransferOwner(myId uint64, d *dto.GroupMemberDto) {
+	g, myRole := checkGroupPermission(myId, d.GroupId, groupActionTransferOwner)
+	checkGroupTarget(g, myRole, d.UserId)
+	myContact := verifyGroupContact(myId, d.GroupId)
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer rollbackWhenPanic(tx)
+	groupDao := di.ENV().GroupDao(tx)
+	groupDao.UpdateOwner(g.GroupId, d.UserId)
+	groupDao.UpdateMemberRole(g.GroupId, d.UserId, entity.GroupRoleOwner)
+	groupDao.UpdateMemberRole(g.GroupId, myId, entity.GroupRoleMember)
+	sendSystemMessage(tx, myContact, myId, &entity.SystemMessage{
+		Event:   entity.SystemEventOwnerTransferred,
+		UserIds: []uint64{d.UserId},
+	})
+	tx.Commit()
+	g.OwnerId = d.UserId
+	notifyGroupUpdated(g)
+}
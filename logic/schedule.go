@@ -0,0 +1,141 @@
+package logic
+
+import (
+	"database/sql"
+	"encoding/json"
+	"ichat-go/di"
+	"ichat-go/errs"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+	"time"
+)
+
+// 定时消息最多提前一年设置
+const maxScheduleAhead = time.Hour * 24 * 365
+
+// 校验定时消息，图片、文件需要已上传完成
+func checkScheduledMessage(myId uint64, d *dto.ScheduleMessageDto) time.Time {
+	checkMessageForm(&d.SendMessageDto)
+	if (d.Thumbnail != "" && d.Image == "") || (d.FileName != "" && d.File == "") {
+		panic(errs.NewAppError(errs.CodeBadRequest, "定时消息需要先上传文件"))
+	}
+	sendAt := time.UnixMilli(d.SendAt)
+	if !sendAt.After(time.Now()) || sendAt.After(time.Now().Add(maxScheduleAhead)) {
+		panic(errs.ScheduleTimeInvalid)
+	}
+	contact := di.ENV().ContactDao().FindContactById(d.ContactId)
+	verifyContact(contact, myId)
+	checkContactWritable(contact)
+	checkReplyMessage(contact, d.ReplyToId)
+	checkMentions(contact, myId, &d.SendMessageDto)
+	return sendAt
+}
+
+func findPendingScheduledMessage(myId uint64, id uint64) *entity.ScheduledMessage {
+	s := di.ENV().ScheduledMessageDao().FindScheduledMessageById(id)
+	if s == nil || s.UserId != myId {
+		panic(errs.Forbidden)
+	}
+	if s.Status != entity.ScheduledMessageStatusPending {
+		panic(errs.ScheduledMessageNotPending)
+	}
+	return s
+}
+
+func ChatScheduleMessage(myId uint64, d *dto.ScheduleMessageDto) *entity.ScheduledMessage {
+	sendAt := checkScheduledMessage(myId, d)
+	d.LocalId = ""
+	b, _ := json.Marshal(&d.SendMessageDto)
+	s := &entity.ScheduledMessage{
+		UserId:    myId,
+		ContactId: d.ContactId,
+		Message:   b,
+		SendAt:    sendAt,
+		Status:    entity.ScheduledMessageStatusPending,
+	}
+	di.ENV().ScheduledMessageDao().CreateScheduledMessage(s)
+	scheduleTask(taskTypeScheduledMessage, s.Id, s.SendAt)
+	return s
+}
+
+// ChatGetScheduledMessages contactId为0时查询所有待发送的定时消息
+func ChatGetScheduledMessages(myId uint64, contactId uint64) []*entity.ScheduledMessage {
+	if contactId != 0 {
+		verifyContact(di.ENV().ContactDao().FindContactById(contactId), myId)
+	}
+	return di.ENV().ScheduledMessageDao().GetPendingScheduledMessages(myId, contactId)
+}
+
+func ChatEditScheduledMessage(myId uint64, d *dto.EditScheduledMessageDto) *entity.ScheduledMessage {
+	s := findPendingScheduledMessage(myId, d.Id)
+	d.ContactId = s.ContactId // 不允许修改发送对象
+	sendAt := checkScheduledMessage(myId, &d.ScheduleMessageDto)
+	d.LocalId = ""
+	s.Message, _ = json.Marshal(&d.SendMessageDto)
+	s.SendAt = sendAt
+	if !di.ENV().ScheduledMessageDao().UpdatePendingScheduledMessage(s) {
+		panic(errs.ScheduledMessageNotPending)
+	}
+	scheduleTask(taskTypeScheduledMessage, s.Id, s.SendAt)
+	return s
+}
+
+func ChatCancelScheduledMessage(myId uint64, id uint64) {
+	findPendingScheduledMessage(myId, id)
+	ok := di.ENV().ScheduledMessageDao().UpdateScheduledMessageStatus(id,
+		entity.ScheduledMessageStatusPending, entity.ScheduledMessageStatusCanceled)
+	if !ok {
+		panic(errs.ScheduledMessageNotPending)
+	}
+	cancelTask(taskTypeScheduledMessage, id)
+}
+
+// 到期发送定时消息，状态更新和发送在同一事务中，保证只发送一次
+func sendScheduledMessage(id uint64) {
+	scheduledMessageDao := di.ENV().ScheduledMessageDao()
+	s := scheduledMessageDao.FindScheduledMessageById(id)
+	if s == nil || s.Status != entity.ScheduledMessageStatusPending {
+		return
+	}
+	if time.Until(s.SendAt) > time.Second {
+		// 发送时间已被修改
+		scheduleTask(taskTypeScheduledMessage, s.Id, s.SendAt)
+		return
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			scheduledMessageDao.UpdateScheduledMessageStatus(id,
+				entity.ScheduledMessageStatusPending, entity.ScheduledMessageStatusFailed)
+			taskLogger.Error("send scheduled message failed: ", id, err)
+		}
+	}()
+	var d dto.SendMessageDto
+	if err := json.Unmarshal(s.Message, &d); err != nil {
+		panic(err)
+	}
+	tx := di.ENV().DB().Begin(&sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	defer commitOrRollback(tx)
+	ok := di.ENV().ScheduledMessageDao(tx).UpdateScheduledMessageStatus(id,
+		entity.ScheduledMessageStatusPending, entity.ScheduledMessageStatusSent)
+	if !ok {
+		return
+	}
+	m := sendMessage(tx, s.UserId, &d)
+	di.ENV().ScheduledMessageDao(tx).SetScheduledMessageSent(id, m.MessageId)
+}
+
+// 启动时重新调度待发送的定时消息，防止任务在取出后未处理完时实例退出而丢失
+func rescheduleScheduledMessages() {
+	defer func() {
+		if err := recover(); err != nil {
+			taskLogger.Error("reschedule scheduled messages failed: ", err)
+		}
+	}()
+	for _, s := range di.ENV().ScheduledMessageDao().GetAllPendingScheduledMessages() {
+		scheduleTask(taskTypeScheduledMessage, s.Id, s.SendAt)
+	}
+}
+
+func init() {
+	taskHandlers[taskTypeScheduledMessage] = sendScheduledMessage
+}
@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"ichat-go/di"
+	"ichat-go/model/dao"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+	"ichat-go/utils/strs"
+	"time"
+)
+
+// indexMessage 同步消息到全文索引，只索引未撤回的文本消息
+func indexMessage(tx dao.Tx, m *entity.ChatMessage) {
+	if m.Type == entity.ChatMessageTypeText && !m.Revoked {
+		di.ENV().MessageIndex(tx).Index(m)
+	} else {
+		di.ENV().MessageIndex(tx).Remove(m.MessageId)
+	}
+}
+
+func ChatSearchMessages(myId uint64, d *dto.SearchMessageDto) []*dto.SearchMessageItem {
+	if d.Limit == 0 {
+		d.Limit = 20
+	}
+	q := &dao.MessageQuery{
+		OwnerId:       myId,
+		Keyword:       d.Keyword,
+		SenderId:      d.SenderId,
+		LastMessageId: d.LastMessageId,
+		Limit:         d.Limit,
+	}
+	if d.ContactId != 0 {
+		contact := di.ENV().ContactDao().FindContactById(d.ContactId)
+		verifyContact(contact, myId)
+		q.RoomId = contact.RoomId
+	}
+	if d.StartTime != 0 {
+		t := time.UnixMilli(d.StartTime)
+		q.StartTime = &t
+	}
+	if d.EndTime != 0 {
+		t := time.UnixMilli(d.EndTime)
+		q.EndTime = &t
+	}
+	messages := di.ENV().MessageIndex().Search(q)
+	contactIds := make(map[uint64]uint64)
+	for _, c := range di.ENV().ContactDao().GetAll(myId) {
+		contactIds[c.RoomId] = c.ContactId
+	}
+	results := make([]*dto.SearchMessageItem, 0, len(messages))
+	for i, m := range messagesToDtos(messages, false) {
+		results = append(results, &dto.SearchMessageItem{
+			ChatMessageDto: *m,
+			ContactId:      contactIds[m.RoomId],
+			Highlights:     strs.IndexAll(messages[i].Text, d.Keyword),
+		})
+	}
+	return results
+}
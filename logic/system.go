@@ -0,0 +1,134 @@
+package logic
+
+import (
+	"fmt"
+	"ichat-go/di"
+	"ichat-go/model/dao"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+	"ichat-go/utils/strs"
+	"strings"
+)
+
+// 在联系人所在的聊天室发送系统消息，operatorId为操作者，系统自动触发时为0
+func sendSystemMessage(tx dao.Tx, contact *entity.Contact, operatorId uint64, s *entity.SystemMessage) *dto.ChatMessageDto {
+	message := &entity.ChatMessage{
+		RoomId:   contact.RoomId,
+		SenderId: operatorId,
+		Type:     entity.ChatMessageTypeSystem,
+		System:   s,
+	}
+	di.ENV().ChatDao(tx).CreateMessage(message)
+	m := messageToDto(message, false)
+	ctx := deliverCtx{
+		tx:      tx,
+		contact: contact,
+		m:       m,
+		new:     true,
+	}
+	ctx.deliver()
+	return m
+}
+
+func describeDuration(seconds int) string {
+	switch {
+	case seconds%(3600*24*7) == 0:
+		return fmt.Sprintf("%d周", seconds/(3600*24*7))
+	case seconds%(3600*24) == 0:
+		return fmt.Sprintf("%d天", seconds/(3600*24))
+	case seconds%3600 == 0:
+		return fmt.Sprintf("%d小时", seconds/3600)
+	case seconds%60 == 0:
+		return fmt.Sprintf("%d分钟", seconds/60)
+	default:
+		return fmt.Sprintf("%d秒", seconds)
+	}
+}
+
+// 系统消息预览中的用户名，最多列出的用户数
+const maxDescribedUsers = 3
+
+func describeUsers(userIds []uint64) string {
+	users := di.ENV().UserDao().FindUsersByUserId(userIds)
+	names := make(map[uint64]string)
+	for _, u := range users {
+		if u.Nickname != "" {
+			names[u.UserId] = u.Nickname
+		} else {
+			names[u.UserId] = u.Username
+		}
+	}
+	var parts []string
+	for i, id := range userIds {
+		if i == maxDescribedUsers {
+			return strings.Join(parts, "、") + fmt.Sprintf("等%d人", len(userIds))
+		}
+		parts = append(parts, names[id])
+	}
+	return strings.Join(parts, "、")
+}
+
+func describeSystemMessage(e *entity.ChatMessage) string {
+	s := e.System
+	if s == nil {
+		return "[系统消息]"
+	}
+	operator := describeUsers([]uint64{e.SenderId})
+	var text string
+	switch s.Event {
+	case entity.SystemEventMessageTTL:
+		if s.Duration == 0 {
+			text = operator + "关闭了消息自动删除"
+		} else {
+			text = operator + "设置新消息在" + describeDuration(s.Duration) + "后自动删除"
+		}
+	case entity.SystemEventMessagePinned:
+		text = operator + "置顶了一条消息"
+	case entity.SystemEventMessageUnpinned:
+		text = operator + "取消置顶了一条消息"
+	case entity.SystemEventGroupCreated:
+		text = operator + "创建了群聊"
+	case entity.SystemEventMembersAdded:
+		text = operator + "邀请" + describeUsers(s.UserIds) + "加入了群聊"
+	case entity.SystemEventMembersRemoved:
+		text = operator + "将" + describeUsers(s.UserIds) + "移出了群聊"
+	case entity.SystemEventMemberLeft:
+		text = operator + "退出了群聊"
+	case entity.SystemEventGroupRenamed:
+		text = operator + "修改群名为“" + s.Name + "”"
+	case entity.SystemEventAdminAdded:
+		text = operator + "将" + describeUsers(s.UserIds) + "设为管理员"
+	case entity.SystemEventAdminRemoved:
+		text = operator + "取消了" + describeUsers(s.UserIds) + "的管理员身份"
+	case entity.SystemEventOwnerTransferred:
+		text = operator + "将群主转让给" + describeUsers(s.UserIds)
+	case entity.SystemEventGroupAvatar:
+		text = operator + "修改了群头像"
+	case entity.SystemEventAnnouncement:
+		if s.Text == "" {
+			text = operator + "清空了群公告"
+		} else {
+			text = operator + "发布了群公告：" + s.Text
+		}
+	case entity.SystemEventMemberJoined:
+		text = operator + "通过邀请链接加入了群聊"
+	case entity.SystemEventGroupDissolved:
+		text = operator + "解散了群聊"
+	case entity.SystemEventMuteAllEnabled:
+		text = operator + "开启了全员禁言"
+	case entity.SystemEventMuteAllDisabled:
+		text = operator + "关闭了全员禁言"
+	case entity.SystemEventMemberMuted:
+		text = operator + "将" + describeUsers(s.UserIds) + "禁言" + describeDuration(s.Duration)
+	case entity.SystemEventMemberUnmuted:
+		if e.SenderId == 0 {
+			// 到期自动解除没有操作人
+			text = describeUsers(s.UserIds) + "的禁言已到期解除"
+		} else {
+			text = operator + "解除了" + describeUsers(s.UserIds) + "的禁言"
+		}
+	default:
+		return "[系统消息]"
+	}
+	return strs.TakeFirstN(text, 50, true)
+}
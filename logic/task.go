@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"fmt"
+	"ichat-go/logging"
+	"ichat-go/sched"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 延迟任务类型
+const (
+	taskTypeScheduledMessage = 1
+	taskTypeMessageExpire    = 2
+	taskTypeMemberUnmute     = 3
+)
+
+var taskDq sched.DQ
+var taskDqOnce sync.Once
+var taskLogger logging.Logger
+
+// 任务处理函数，由各模块在init中注册
+var taskHandlers = map[int]func(id uint64){}
+
+func tasks() sched.DQ {
+	taskDqOnce.Do(func() {
+		taskDq = sched.NewDQ("logic:task")
+	})
+	return taskDq
+}
+
+func taskId(taskType int, id uint64) string {
+	return fmt.Sprintf("%d:%d", taskType, id)
+}
+
+// 同一任务重复调度会覆盖之前的执行时间
+func scheduleTask(taskType int, id uint64, t time.Time) {
+	m := sched.Message{
+		Id:      taskId(taskType, id),
+		Type:    taskType,
+		Payload: []byte(strconv.FormatUint(id, 10)),
+	}
+	if err := tasks().Schedule(t, m); err != nil {
+		panic(err)
+	}
+}
+
+func cancelTask(taskType int, id uint64) {
+	tasks().Delete(taskId(taskType, id))
+}
+
+func runTask(m sched.DelayMessage) {
+	defer func() {
+		if err := recover(); err != nil {
+			taskLogger.Error("task panic: ", m.Id, err)
+		}
+	}()
+	handler := taskHandlers[m.Type]
+	if handler == nil {
+		taskLogger.Error("unknown task type: ", m.Type)
+		return
+	}
+	id, _ := strconv.ParseUint(string(m.Payload), 10, 64)
+	handler(id)
+}
+
+// TaskLoop 处理到期的延迟任务，多实例下每个任务只会被一个实例取出
+func TaskLoop() {
+	taskLogger = logging.NewLogger("logic:task")
+	rescheduleScheduledMessages()
+	rescheduleMemberUnmutes()
+	for m := range tasks().Channel() {
+		go runTask(m)
+	}
+}
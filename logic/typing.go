@@ -0,0 +1,29 @@
+package logic
+
+import (
+	"ichat-go/di"
+	"ichat-go/logic/notification"
+	"ichat-go/model/dto"
+	"time"
+)
+
+const typingTTL = time.Second * 6
+
+// ChatTyping 转发正在输入状态，不持久化，也不产生投递记录
+func ChatTyping(myId uint64, d *dto.TypingDto) {
+	contact := di.ENV().ContactDao().FindContactById(d.ContactId)
+	verifyContact(contact, myId)
+	checkContactWritable(contact)
+	userIds := di.ENV().ChatDao().GetRoomMemberUserIds(contact.RoomId)
+	t := &dto.TypingNotificationDto{
+		RoomId:   contact.RoomId,
+		UserId:   myId,
+		Typing:   d.Typing,
+		ExpireAt: time.Now().Add(typingTTL).UnixMilli(),
+	}
+	for _, userId := range userIds {
+		if userId != myId {
+			notification.SendTyping(userId, t)
+		}
+	}
+}
@@ -17,6 +17,8 @@ type CallDao interface {
 	UpdateEndReasonAndTime(callId uint64, reason int)
 	SetHandled(callId, userId uint64)
 	IsHandled(callId, userId uint64) bool
+	GetHandledUserIds(callId uint64) []uint64
+	GetActiveCallIds(roomId uint64) []uint64
 }
 
 func callHandledKey(callId uint64) string {
@@ -91,6 +93,25 @@ func (d callDao) IsHandled(callId, userId uint64) bool {
 	return c.SIsMember(c.Context(), key, userId).Val()
 }
 
+func (d callDao) GetHandledUserIds(callId uint64) []uint64 {
+	key := callHandledKey(callId)
+	c := rdb()
+	var ids []uint64
+	_ = c.SMembers(c.Context(), key).ScanSlice(&ids)
+	return ids
+}
+
+// GetActiveCallIds 查询聊天室中未结束的通话
+func (d callDao) GetActiveCallIds(roomId uint64) []uint64 {
+	var ids []uint64
+	tx := d.tx.Model(&entity.Call{}).
+		Joins("join chat_messages on chat_messages.message_id = calls.message_id").
+		Where("chat_messages.room_id = ? and calls.status != ?", roomId, entity.CallStatusEnd).
+		Pluck("calls.call_id", &ids)
+	assertNoError(tx)
+	return ids
+}
+
 func NewCallDao(tx Tx) CallDao {
 	return &callDao{tx: tx}
 }
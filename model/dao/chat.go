@@ -3,23 +3,49 @@ package dao
 import (
 	"fmt"
 	_ "gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"ichat-go/model/entity"
 	"ichat-go/utils"
+	"time"
 )
 
 type ChatDao interface {
-	CreateChatRoom(e *entity.ChatRoom)
-	UpdateRoomName(e *entity.ChatRoom)
+	CreateChatRoom(e *entity.ChatRoom, userIds []uint64)
 	FindRoomById(roomId uint64) *entity.ChatRoom
+	LockRoom(roomId uint64)
 	FindOrCreateChatRoomForContact(c *entity.Contact)
+	AddRoomMembers(roomId uint64, userIds []uint64)
+	RemoveRoomMembers(roomId uint64, userIds []uint64)
+	GetRoomMemberUserIds(roomId uint64) []uint64
 	CreateMessage(e *entity.ChatMessage)
 	UpdateMessage(e *entity.ChatMessage)
 	FindMessageById(messageId uint64) *entity.ChatMessage
+	FindMessagesByIds(messageIds []uint64) []*entity.ChatMessage
+	GetReplyMessageIds(messageId uint64) []uint64
 	UpdateCallId(e *entity.ChatMessage)
-	CreateDelivery(e *entity.MessageDelivery)
-	GetMessages(roomId uint64, lastMessageId uint64, limit int) []*entity.ChatMessage
+	CreateMessageEdit(e *entity.ChatMessageEdit)
+	GetMessageEdits(messageId uint64) []*entity.ChatMessageEdit
+	DeleteMessageEdits(messageId uint64)
+	SaveReaction(e *entity.MessageReaction)
+	DeleteReaction(messageId, userId uint64) bool
+	GetReactions(messageId uint64) []*entity.MessageReaction
+	CountReactions(messageIds []uint64) []*ReactionCount
+	CreateDeliveries(list []*entity.MessageDelivery)
+	UpdateDeliveryStatus(receiverId uint64, scope DeliveryScope, status int) []*DeliveryReceipt
+	GetMessageReceipts(messageId uint64) []*MessageReceipt
+	GetMessages(userId uint64, roomId uint64, lastMessageId uint64, limit int) []*entity.ChatMessage
 	FindLastMessage(roomId uint64) *entity.ChatMessage
+	FindLastVisibleMessage(userId uint64, roomId uint64) *entity.ChatMessage
+	FindVisibleMessagesByIds(userId uint64, messageIds []uint64) []*entity.ChatMessage
+	HideMessages(userId uint64, messageIds []uint64)
+	CreatePin(e *entity.PinnedMessage)
+	FindPin(roomId uint64, messageId uint64) *entity.PinnedMessage
+	DeletePin(roomId uint64, messageId uint64) bool
+	GetPins(roomId uint64) []*entity.PinnedMessage
 	FindLastMessageId(roomId uint64) uint64
+	CountMessagesAfter(receiverId uint64, roomId uint64, messageId uint64) int
+	GetUnreadMentions(receiverId uint64, roomId uint64) []*entity.ChatMessage
+	CountUnreadMentions(receiverId uint64, roomId uint64) int
 	GetDeliveries(receiverId, lastId, syncedId uint64, limit int) []*DeliveryMessage
 	FindLastDeliveryId(receiverId uint64) uint64
 }
@@ -33,12 +59,76 @@ type DeliveryMessage struct {
 	DeliveryId uint64 `json:"deliveryId"`
 }
 
-func (d chatDao) CreateChatRoom(e *entity.ChatRoom) {
+type ReactionCount struct {
+	MessageId uint64
+	Emoji     string
+	Count     int
+}
+
+// DeliveryScope 批量更新投递状态的范围，按投递记录id、按聊天室消息id或单条消息
+type DeliveryScope struct {
+	LastDeliveryId uint64
+	RoomId         uint64
+	LastMessageId  uint64
+	MessageId      uint64 // 仅单条消息
+}
+
+func (s DeliveryScope) apply(tx Tx) Tx {
+	if s.LastDeliveryId != 0 {
+		tx = tx.Where("message_deliveries.id <= ?", s.LastDeliveryId)
+	}
+	if s.RoomId != 0 {
+		tx = tx.Where("message_deliveries.message_id in (select message_id from chat_messages where room_id = ? and message_id <= ?)",
+			s.RoomId, s.LastMessageId)
+	}
+	if s.MessageId != 0 {
+		tx = tx.Where("message_deliveries.message_id = ?", s.MessageId)
+	}
+	return tx
+}
+
+// DeliveryReceipt 按聊天室和发送者聚合的回执
+type DeliveryReceipt struct {
+	RoomId    uint64
+	SenderId  uint64
+	MessageId uint64
+}
+
+type MessageReceipt struct {
+	UserId    uint64    `json:"userId"`
+	Status    int       `json:"status"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CreateChatRoom 创建聊天室及其成员
+func (d chatDao) CreateChatRoom(e *entity.ChatRoom, userIds []uint64) {
 	assertNoError(d.tx.Create(e))
+	d.AddRoomMembers(e.RoomId, userIds)
+}
+
+// AddRoomMembers 已经是成员的用户会被忽略
+func (d chatDao) AddRoomMembers(roomId uint64, userIds []uint64) {
+	if len(userIds) == 0 {
+		return
+	}
+	members := make([]*entity.RoomMember, 0, len(userIds))
+	for _, userId := range userIds {
+		members = append(members, &entity.RoomMember{RoomId: roomId, UserId: userId})
+	}
+	tx := d.tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&members)
+	assertNoError(tx)
 }
 
-func (d chatDao) UpdateRoomName(e *entity.ChatRoom) {
-	assertNoError(d.tx.Model(e).Update("name", e.Name))
+func (d chatDao) RemoveRoomMembers(roomId uint64, userIds []uint64) {
+	tx := d.tx.Where("room_id = ? and user_id in ?", roomId, userIds).Delete(&entity.RoomMember{})
+	assertNoError(tx)
+}
+
+func (d chatDao) GetRoomMemberUserIds(roomId uint64) []uint64 {
+	var ids []uint64
+	tx := d.tx.Model(&entity.RoomMember{}).Where("room_id = ?", roomId).Pluck("user_id", &ids)
+	assertNoError(tx)
+	return ids
 }
 
 func (d chatDao) FindRoomById(roomId uint64) *entity.ChatRoom {
@@ -50,6 +140,12 @@ func (d chatDao) FindRoomById(roomId uint64) *entity.ChatRoom {
 	return &room
 }
 
+// LockRoom 聊天室加行锁(select ... for update)，需要在事务中调用
+func (d chatDao) LockRoom(roomId uint64) {
+	var room entity.ChatRoom
+	assertNoError(d.tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&room, roomId))
+}
+
 func (d chatDao) FindOrCreateChatRoomForContact(c *entity.Contact) {
 	if c.RoomId != 0 {
 		return
@@ -72,9 +168,10 @@ func (d chatDao) FindOrCreateChatRoomForContact(c *entity.Contact) {
 	tx := d.tx.Select("room_id").First(&room, "name = ?", name)
 	if checkIsEmpty(tx) {
 		room = entity.ChatRoom{
+			Type: entity.ChatRoomTypeUser,
 			Name: name,
 		}
-		d.CreateChatRoom(&room)
+		d.CreateChatRoom(&room, []uint64{c.OwnerId, c.UserId})
 	}
 	c.RoomId = room.RoomId
 }
@@ -85,12 +182,11 @@ func (d chatDao) CreateMessage(e *entity.ChatMessage) {
 }
 
 func (d chatDao) UpdateMessage(e *entity.ChatMessage) {
-	assertNoError(d.tx.Model(e).Updates(map[string]interface{}{
-		"text":      e.Text,
-		"image":     e.Image,
-		"thumbnail": e.Thumbnail,
-		"revoked":   e.Revoked,
-	}))
+	// 用Select指定列，零值也会更新，waveform按json序列化
+	assertNoError(d.tx.Model(e).
+		Select("text", "image", "thumbnail", "file", "file_name", "file_size", "mime_type",
+			"audio", "duration", "waveform", "record", "revoked", "expired", "edited_at").
+		Updates(e))
 }
 
 func (d chatDao) FindMessageById(messageId uint64) *entity.ChatMessage {
@@ -102,17 +198,121 @@ func (d chatDao) FindMessageById(messageId uint64) *entity.ChatMessage {
 	return &message
 }
 
+func (d chatDao) FindMessagesByIds(messageIds []uint64) []*entity.ChatMessage {
+	var messages []*entity.ChatMessage
+	tx := d.tx.Where("message_id in ?", messageIds).Order("message_id ASC").Find(&messages)
+	assertNoError(tx)
+	return messages
+}
+
+func (d chatDao) GetReplyMessageIds(messageId uint64) []uint64 {
+	var ids []uint64
+	tx := d.tx.Model(&entity.ChatMessage{}).Where("reply_to_id = ?", messageId).Pluck("message_id", &ids)
+	assertNoError(tx)
+	return ids
+}
+
 func (d chatDao) UpdateCallId(e *entity.ChatMessage) {
 	assertNoError(d.tx.Model(e).Update("call_id", e.CallId))
 }
 
-func (d chatDao) CreateDelivery(e *entity.MessageDelivery) {
+func (d chatDao) CreateMessageEdit(e *entity.ChatMessageEdit) {
 	assertNoError(d.tx.Create(e))
 }
 
-func (d chatDao) GetMessages(roomId uint64, lastMessageId uint64, limit int) []*entity.ChatMessage {
+func (d chatDao) GetMessageEdits(messageId uint64) []*entity.ChatMessageEdit {
+	var edits []*entity.ChatMessageEdit
+	tx := d.tx.Where("message_id = ?", messageId).Order("id DESC").Find(&edits)
+	assertNoError(tx)
+	return edits
+}
+
+func (d chatDao) DeleteMessageEdits(messageId uint64) {
+	assertNoError(d.tx.Where("message_id = ?", messageId).Delete(&entity.ChatMessageEdit{}))
+}
+
+func (d chatDao) SaveReaction(e *entity.MessageReaction) {
+	tx := d.tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"emoji", "updated_at"}),
+	}).Create(e)
+	assertNoError(tx)
+}
+
+func (d chatDao) DeleteReaction(messageId, userId uint64) bool {
+	tx := d.tx.Where("message_id = ? and user_id = ?", messageId, userId).Delete(&entity.MessageReaction{})
+	return rowsAffected(tx) > 0
+}
+
+func (d chatDao) GetReactions(messageId uint64) []*entity.MessageReaction {
+	var reactions []*entity.MessageReaction
+	tx := d.tx.Where("message_id = ?", messageId).Order("created_at ASC").Find(&reactions)
+	assertNoError(tx)
+	return reactions
+}
+
+func (d chatDao) CountReactions(messageIds []uint64) []*ReactionCount {
+	var counts []*ReactionCount
+	if len(messageIds) == 0 {
+		return counts
+	}
+	tx := d.tx.Model(&entity.MessageReaction{}).
+		Select("message_id, emoji, count(*) as count").
+		Where("message_id in ?", messageIds).
+		Group("message_id, emoji").
+		Order("min(created_at) ASC").
+		Find(&counts)
+	assertNoError(tx)
+	return counts
+}
+
+// 批量插入投递记录的每批数量
+const deliveryBatchSize = 500
+
+// CreateDeliveries 批量插入投递记录，插入后回填记录id
+func (d chatDao) CreateDeliveries(list []*entity.MessageDelivery) {
+	if len(list) == 0 {
+		return
+	}
+	assertNoError(d.tx.CreateInBatches(list, deliveryBatchSize))
+}
+
+func (d chatDao) UpdateDeliveryStatus(receiverId uint64, scope DeliveryScope, status int) []*DeliveryReceipt {
+	var receipts []*DeliveryReceipt
+	tx := scope.apply(d.tx.Model(&entity.MessageDelivery{})).
+		Select("chat_messages.room_id, chat_messages.sender_id, max(chat_messages.message_id) as message_id").
+		Joins("LEFT JOIN chat_messages ON message_deliveries.message_id = chat_messages.message_id").
+		Where("message_deliveries.receiver_id = ? and message_deliveries.status < ?", receiverId, status).
+		Where("chat_messages.sender_id != ?", receiverId).
+		Group("chat_messages.room_id, chat_messages.sender_id").
+		Find(&receipts)
+	assertNoError(tx)
+	tx = scope.apply(d.tx.Model(&entity.MessageDelivery{})).
+		Where("message_deliveries.receiver_id = ? and message_deliveries.status < ?", receiverId, status).
+		Update("status", status)
+	assertNoError(tx)
+	return receipts
+}
+
+func (d chatDao) GetMessageReceipts(messageId uint64) []*MessageReceipt {
+	var receipts []*MessageReceipt
+	tx := d.tx.Model(&entity.MessageDelivery{}).
+		Select("receiver_id as user_id, max(status) as status, max(updated_at) as updated_at").
+		Where("message_id = ?", messageId).
+		Group("receiver_id").
+		Find(&receipts)
+	assertNoError(tx)
+	return receipts
+}
+
+// 用户可见的消息：未被该用户删除，且在清空聊天记录之后
+func visibleTo(tx Tx, userId uint64) Tx {
+	return tx.Where("chat_messages.message_id not in (select message_id from message_hides where user_id = ?)", userId).
+		Where("chat_messages.message_id > (select coalesce(max(cleared_msg_id), 0) from contacts where owner_id = ? and room_id = chat_messages.room_id)", userId)
+}
+
+func (d chatDao) GetMessages(userId uint64, roomId uint64, lastMessageId uint64, limit int) []*entity.ChatMessage {
 	var messages []*entity.ChatMessage
-	tx := d.tx.Where("room_id = ?", roomId).Order("message_id desc").Limit(limit)
+	tx := visibleTo(d.tx, userId).Where("room_id = ?", roomId).Order("message_id desc").Limit(limit)
 	if lastMessageId != 0 {
 		tx = tx.Where("message_id < ?", lastMessageId)
 	}
@@ -129,6 +329,54 @@ func (d chatDao) FindLastMessage(roomId uint64) *entity.ChatMessage {
 	return &message
 }
 
+func (d chatDao) FindLastVisibleMessage(userId uint64, roomId uint64) *entity.ChatMessage {
+	var message entity.ChatMessage
+	tx := visibleTo(d.tx, userId).Where("room_id = ?", roomId).Order("message_id desc").First(&message)
+	if checkIsEmpty(tx) {
+		return nil
+	}
+	return &message
+}
+
+func (d chatDao) FindVisibleMessagesByIds(userId uint64, messageIds []uint64) []*entity.ChatMessage {
+	var messages []*entity.ChatMessage
+	tx := visibleTo(d.tx, userId).Where("message_id in ?", messageIds).Order("message_id ASC").Find(&messages)
+	assertNoError(tx)
+	return messages
+}
+
+func (d chatDao) HideMessages(userId uint64, messageIds []uint64) {
+	hides := make([]*entity.MessageHide, 0, len(messageIds))
+	for _, id := range messageIds {
+		hides = append(hides, &entity.MessageHide{UserId: userId, MessageId: id})
+	}
+	assertNoError(d.tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&hides))
+}
+
+func (d chatDao) CreatePin(e *entity.PinnedMessage) {
+	assertNoError(d.tx.Create(e))
+}
+
+func (d chatDao) FindPin(roomId uint64, messageId uint64) *entity.PinnedMessage {
+	var pin entity.PinnedMessage
+	tx := d.tx.Where("room_id = ? and message_id = ?", roomId, messageId).First(&pin)
+	if checkIsEmpty(tx) {
+		return nil
+	}
+	return &pin
+}
+
+func (d chatDao) DeletePin(roomId uint64, messageId uint64) bool {
+	tx := d.tx.Where("room_id = ? and message_id = ?", roomId, messageId).Delete(&entity.PinnedMessage{})
+	return rowsAffected(tx) > 0
+}
+
+func (d chatDao) GetPins(roomId uint64) []*entity.PinnedMessage {
+	var pins []*entity.PinnedMessage
+	assertNoError(d.tx.Where("room_id = ?", roomId).Order("id DESC").Find(&pins))
+	return pins
+}
+
 func (d chatDao) FindLastMessageId(roomId uint64) uint64 {
 	var message entity.ChatMessage
 	tx := d.tx.Where("room_id = ?", roomId).Order("message_id desc").First(&message)
@@ -138,8 +386,39 @@ func (d chatDao) FindLastMessageId(roomId uint64) uint64 {
 	return message.MessageId
 }
 
+// CountMessagesAfter 统计消息之后他人发送且接收者未删除的消息数
+func (d chatDao) CountMessagesAfter(receiverId uint64, roomId uint64, messageId uint64) int {
+	var count int64
+	tx := visibleTo(d.tx.Model(&entity.ChatMessage{}), receiverId).
+		Where("room_id = ? and message_id > ? and sender_id != ?", roomId, messageId, receiverId).
+		Count(&count)
+	assertNoError(tx)
+	return int(count)
+}
+
+// 未读的@我的消息，不包括接收者已删除的消息
+func (d chatDao) unreadMentionsScope(receiverId uint64, roomId uint64) Tx {
+	return visibleTo(d.tx.Model(&entity.ChatMessage{}), receiverId).
+		Where("room_id = ?", roomId).
+		Where("message_id in (select message_id from message_deliveries where receiver_id = ? and mentioned = ? and status < ?)",
+			receiverId, true, entity.MessageDeliveryStatusRead)
+}
+
+func (d chatDao) GetUnreadMentions(receiverId uint64, roomId uint64) []*entity.ChatMessage {
+	var messages []*entity.ChatMessage
+	tx := d.unreadMentionsScope(receiverId, roomId).Order("message_id ASC").Find(&messages)
+	assertNoError(tx)
+	return messages
+}
+
+func (d chatDao) CountUnreadMentions(receiverId uint64, roomId uint64) int {
+	var count int64
+	assertNoError(d.unreadMentionsScope(receiverId, roomId).Count(&count))
+	return int(count)
+}
+
 func (d chatDao) GetDeliveries(receiverId, lastId, syncedId uint64, limit int) []*DeliveryMessage {
-	tx := d.tx.Model(&entity.MessageDelivery{}).
+	tx := visibleTo(d.tx.Model(&entity.MessageDelivery{}), receiverId).
 		Select("chat_messages.*, message_deliveries.id as delivery_id").
 		Joins("LEFT JOIN chat_messages ON message_deliveries.message_id = chat_messages.message_id").
 		Where("message_deliveries.id > ?", syncedId).
@@ -1,6 +1,7 @@
 package dao
 
 import (
+	"gorm.io/gorm"
 	"ichat-go/model/entity"
 	"ichat-go/utils"
 )
@@ -9,6 +10,7 @@ type ContactDao interface {
 	FindUserContact(ownerId uint64, userId uint64) *entity.Contact
 	FindGroupContact(ownerId uint64, groupId uint64) *entity.Contact
 	FindContactById(id uint64) *entity.Contact
+	FindContactByRoomId(ownerId uint64, roomId uint64) *entity.Contact
 	CreateContact(c *entity.Contact)
 	FindPendingRequest(uid1 uint64, uid2 uint64) *entity.ContactRequest
 	CreateContactRequest(c *entity.ContactRequest)
@@ -16,8 +18,18 @@ type ContactDao interface {
 	UpdateContactRequestStatus(id uint64, status int)
 	CheckContactExists(ownerId uint64, userId uint64) bool
 	GetAll(ownerId uint64) []*entity.Contact
+	GetContactsByRoomId(roomId uint64) []*entity.Contact
 	GetAllPendingRequests(receiverId uint64) []*entity.ContactRequest
 	UpdateLastMessageByRoomId(c *entity.Contact)
+	IncreaseUnreadCount(roomId uint64, senderId uint64)
+	IncreaseMentionCount(roomId uint64, userIds []uint64)
+	UpdateReadState(c *entity.Contact)
+	UpdateMessageTTL(roomId uint64, ttl int)
+	UpdateStatus(contactId uint64, status int)
+	UpdateStatusByRoomId(roomId uint64, status int)
+	UpdateLastMessage(c *entity.Contact)
+	UpdateClearedMessageId(contactId uint64, messageId uint64)
+	DeleteContact(contactId uint64)
 }
 
 type contactDao struct {
@@ -51,6 +63,15 @@ func (d contactDao) FindContactById(id uint64) *entity.Contact {
 	return &contact
 }
 
+func (d contactDao) FindContactByRoomId(ownerId uint64, roomId uint64) *entity.Contact {
+	var contact entity.Contact
+	tx := d.tx.First(&contact, "owner_id = ? and room_id = ?", ownerId, roomId)
+	if checkIsEmpty(tx) {
+		return nil
+	}
+	return &contact
+}
+
 func (d contactDao) CreateContact(c *entity.Contact) {
 	utils.Assert(c.RoomId != 0)
 	tx := d.tx
@@ -108,6 +129,12 @@ func (d contactDao) GetAll(ownerId uint64) []*entity.Contact {
 	return contacts
 }
 
+func (d contactDao) GetContactsByRoomId(roomId uint64) []*entity.Contact {
+	var contacts []*entity.Contact
+	assertNoError(d.tx.Where("room_id = ?", roomId).Find(&contacts))
+	return contacts
+}
+
 func (d contactDao) GetAllPendingRequests(receiverId uint64) []*entity.ContactRequest {
 	var requests []*entity.ContactRequest
 	tx := d.tx.Where("user_id = ? and status = ?", receiverId, entity.ContactRequestStatusPending).
@@ -125,10 +152,81 @@ func (d contactDao) UpdateLastMessageByRoomId(c *entity.Contact) {
 	tx := d.tx.Model(&entity.Contact{}).
 		Where("room_id = ?", c.RoomId).
 		Where("(select message_id from chat_messages where room_id = ? order by message_id desc limit 1) = ?", c.RoomId, c.LastMessageId).
+		Where("owner_id not in (select user_id from message_hides where message_id = ?)", c.LastMessageId).
+		Where("(cleared_msg_id is null or cleared_msg_id < ?)", c.LastMessageId).
 		UpdateColumns(update)
 	assertNoError(tx)
 }
 
+func (d contactDao) IncreaseUnreadCount(roomId uint64, senderId uint64) {
+	tx := d.tx.Model(&entity.Contact{}).
+		Where("room_id = ? and owner_id != ? and status = ?", roomId, senderId, entity.ContactStatusNormal).
+		UpdateColumn("unread_count", gorm.Expr("unread_count + 1"))
+	assertNoError(tx)
+}
+
+func (d contactDao) IncreaseMentionCount(roomId uint64, userIds []uint64) {
+	tx := d.tx.Model(&entity.Contact{}).
+		Where("room_id = ? and owner_id in ? and status = ?", roomId, userIds, entity.ContactStatusNormal).
+		UpdateColumn("mention_count", gorm.Expr("mention_count + 1"))
+	assertNoError(tx)
+}
+
+func (d contactDao) UpdateReadState(c *entity.Contact) {
+	tx := d.tx.Model(&entity.Contact{}).
+		Where("contact_id = ?", c.ContactId).
+		UpdateColumns(map[string]any{
+			"last_read_msg_id": c.LastReadMessageId,
+			"unread_count":     c.UnreadCount,
+			"mention_count":    c.MentionCount,
+		})
+	assertNoError(tx)
+}
+
+// UpdateMessageTTL 同一聊天室的联系人共用消息自动删除时间
+func (d contactDao) UpdateMessageTTL(roomId uint64, ttl int) {
+	tx := d.tx.Model(&entity.Contact{}).
+		Where("room_id = ?", roomId).
+		UpdateColumn("message_ttl", ttl)
+	assertNoError(tx)
+}
+
+func (d contactDao) UpdateStatus(contactId uint64, status int) {
+	tx := d.tx.Model(&entity.Contact{}).
+		Where("contact_id = ?", contactId).
+		Update("status", status)
+	assertNoError(tx)
+}
+
+func (d contactDao) UpdateStatusByRoomId(roomId uint64, status int) {
+	tx := d.tx.Model(&entity.Contact{}).
+		Where("room_id = ?", roomId).
+		Update("status", status)
+	assertNoError(tx)
+}
+
+func (d contactDao) UpdateLastMessage(c *entity.Contact) {
+	tx := d.tx.Model(&entity.Contact{}).
+		Where("contact_id = ?", c.ContactId).
+		UpdateColumns(map[string]any{
+			"last_msg_id":      c.LastMessageId,
+			"last_msg_time":    c.LastMessageTime,
+			"last_msg_content": c.LastMessageContent,
+		})
+	assertNoError(tx)
+}
+
+func (d contactDao) UpdateClearedMessageId(contactId uint64, messageId uint64) {
+	tx := d.tx.Model(&entity.Contact{}).
+		Where("contact_id = ?", contactId).
+		UpdateColumn("cleared_msg_id", messageId)
+	assertNoError(tx)
+}
+
+func (d contactDao) DeleteContact(contactId uint64) {
+	assertNoError(d.tx.Delete(&entity.Contact{}, contactId))
+}
+
 func NewContactDao(tx Tx) ContactDao {
 	return contactDao{tx: tx}
 }
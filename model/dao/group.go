@@ -1,16 +1,40 @@
 package dao
 
 import (
+	"gorm.io/gorm/clause"
 	"ichat-go/model/entity"
+	"time"
 )
 
 type GroupDao interface {
 	CreateGroup(g *entity.Group)
-	GetMemberUserIds(groupId uint64) []uint64
+	GetAdminUserIds(groupId uint64) []uint64
 	CreateMember(g *entity.Group, userIds []uint64)
 	FindGroupById(groupId uint64) *entity.Group
+	LockGroup(groupId uint64) *entity.Group
 	FindGroups(groupIds []uint64) []*entity.Group
-	GetMembers(groupId uint64) []*entity.User
+	GetMembers(groupId uint64) []*Member
+	UpdateMemberCanPin(groupId uint64, memberCanPin bool)
+	Dissolve(groupId uint64) bool
+	UpdateMuteAll(groupId uint64, muteAll bool)
+	FindMemberById(id uint64) *entity.GroupMember
+	UpdateMemberMutedUntil(groupId uint64, userId uint64, mutedUntil *time.Time)
+	ClearExpiredMute(id uint64, mutedUntil time.Time) bool
+	GetMutedMembers() []*entity.GroupMember
+	DeleteMembers(groupId uint64, userIds []uint64)
+	FindMember(groupId uint64, userId uint64) *entity.GroupMember
+	UpdateMemberRole(groupId uint64, userId uint64, role int)
+	UpdateOwner(groupId uint64, ownerId uint64)
+	UpdateProfile(g *entity.Group)
+	CreateAnnouncement(a *entity.GroupAnnouncement)
+	GetAnnouncements(groupId uint64, lastId uint64, limit int) []*entity.GroupAnnouncement
+}
+
+// Member 聊天成员，Role为群成员角色，用户联系人为0
+type Member struct {
+	entity.User
+	Role       int        `json:"role,omitempty"`
+	MutedUntil *time.Time `json:"mutedUntil,omitempty"`
 }
 
 type groupDao struct {
@@ -21,9 +45,13 @@ func (d groupDao) CreateGroup(g *entity.Group) {
 	assertNoError(d.tx.Create(g))
 }
 
-func (d groupDao) GetMemberUserIds(groupId uint64) []uint64 {
+// GetAdminUserIds 群主和管理员的用户id
+func (d groupDao) GetAdminUserIds(groupId uint64) []uint64 {
 	var ids []uint64
-	d.tx.Model(&entity.GroupMember{}).Where("group_id = ?", groupId).Pluck("user_id", &ids)
+	tx := d.tx.Model(&entity.GroupMember{}).
+		Where("group_id = ? and role <= ?", groupId, entity.GroupRoleAdmin).
+		Pluck("user_id", &ids)
+	assertNoError(tx)
 	return ids
 }
 
@@ -32,6 +60,10 @@ func (d groupDao) CreateMember(g *entity.Group, userIds []uint64) {
 		m := &entity.GroupMember{
 			GroupId: g.GroupId,
 			UserId:  userId,
+			Role:    entity.GroupRoleMember,
+		}
+		if userId == g.OwnerId {
+			m.Role = entity.GroupRoleOwner
 		}
 		assertNoError(d.tx.Create(m))
 	}
@@ -46,6 +78,16 @@ func (d groupDao) FindGroupById(groupId uint64) *entity.Group {
 	return &group
 }
 
+// LockGroup 查询群并加行锁(select ... for update)，需要在事务中调用
+func (d groupDao) LockGroup(groupId uint64) *entity.Group {
+	var group entity.Group
+	tx := d.tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, groupId)
+	if checkIsEmpty(tx) {
+		return nil
+	}
+	return &group
+}
+
 func (d groupDao) FindGroups(groupIds []uint64) []*entity.Group {
 	var groups []*entity.Group
 	d.tx.Find(&groups, groupIds)
@@ -61,9 +103,9 @@ func (d groupDao) FindGroups(groupIds []uint64) []*entity.Group {
 	return results
 }
 
-func (d groupDao) GetMembers(groupId uint64) []*entity.User {
-	var members []*entity.User
-	tx := d.tx.Model(&entity.GroupMember{}).Select("users.*").
+func (d groupDao) GetMembers(groupId uint64) []*Member {
+	var members []*Member
+	tx := d.tx.Model(&entity.GroupMember{}).Select("users.*, group_members.role, group_members.muted_until").
 		Joins("LEFT JOIN users ON group_members.user_id = users.user_id").
 		Where("group_id = ?", groupId).
 		Find(&members)
@@ -73,6 +115,112 @@ func (d groupDao) GetMembers(groupId uint64) []*entity.User {
 	return members
 }
 
+func (d groupDao) UpdateMemberCanPin(groupId uint64, memberCanPin bool) {
+	tx := d.tx.Model(&entity.Group{}).
+		Where("group_id = ?", groupId).
+		Update("member_can_pin", memberCanPin)
+	assertNoError(tx)
+}
+
+// Dissolve 标记群已解散，返回是否成功，群已解散时返回false
+func (d groupDao) Dissolve(groupId uint64) bool {
+	tx := d.tx.Model(&entity.Group{}).
+		Where("group_id = ? and dissolved = ?", groupId, false).
+		Update("dissolved", true)
+	return rowsAffected(tx) > 0
+}
+
+func (d groupDao) UpdateMuteAll(groupId uint64, muteAll bool) {
+	tx := d.tx.Model(&entity.Group{}).
+		Where("group_id = ?", groupId).
+		Update("mute_all", muteAll)
+	assertNoError(tx)
+}
+
+func (d groupDao) FindMemberById(id uint64) *entity.GroupMember {
+	var member entity.GroupMember
+	tx := d.tx.First(&member, id)
+	if checkIsEmpty(tx) {
+		return nil
+	}
+	return &member
+}
+
+// UpdateMemberMutedUntil mutedUntil为空时解除禁言
+func (d groupDao) UpdateMemberMutedUntil(groupId uint64, userId uint64, mutedUntil *time.Time) {
+	tx := d.tx.Model(&entity.GroupMember{}).
+		Where("group_id = ? and user_id = ?", groupId, userId).
+		Update("muted_until", mutedUntil)
+	assertNoError(tx)
+}
+
+// ClearExpiredMute 禁言到期时间未被修改时才解除，返回是否成功
+func (d groupDao) ClearExpiredMute(id uint64, mutedUntil time.Time) bool {
+	tx := d.tx.Model(&entity.GroupMember{}).
+		Where("id = ? and muted_until = ?", id, mutedUntil).
+		Update("muted_until", nil)
+	return rowsAffected(tx) > 0
+}
+
+func (d groupDao) GetMutedMembers() []*entity.GroupMember {
+	var members []*entity.GroupMember
+	tx := d.tx.Select("id, muted_until").
+		Where("muted_until is not null").
+		Find(&members)
+	assertNoError(tx)
+	return members
+}
+
+func (d groupDao) DeleteMembers(groupId uint64, userIds []uint64) {
+	tx := d.tx.Where("group_id = ? and user_id in ?", groupId, userIds).Delete(&entity.GroupMember{})
+	assertNoError(tx)
+}
+
+func (d groupDao) FindMember(groupId uint64, userId uint64) *entity.GroupMember {
+	var member entity.GroupMember
+	tx := d.tx.First(&member, "group_id = ? and user_id = ?", groupId, userId)
+	if checkIsEmpty(tx) {
+		return nil
+	}
+	return &member
+}
+
+func (d groupDao) UpdateMemberRole(groupId uint64, userId uint64, role int) {
+	tx := d.tx.Model(&entity.GroupMember{}).
+		Where("group_id = ? and user_id = ?", groupId, userId).
+		Update("role", role)
+	assertNoError(tx)
+}
+
+func (d groupDao) UpdateOwner(groupId uint64, ownerId uint64) {
+	tx := d.tx.Model(&entity.Group{}).
+		Where("group_id = ?", groupId).
+		Update("owner_id", ownerId)
+	assertNoError(tx)
+}
+
+// UpdateProfile 更新群名、群头像和当前群公告
+func (d groupDao) UpdateProfile(g *entity.Group) {
+	tx := d.tx.Model(g).
+		Select("name", "avatar", "announcement", "announcement_by", "announcement_at").
+		Updates(g)
+	assertNoError(tx)
+}
+
+func (d groupDao) CreateAnnouncement(a *entity.GroupAnnouncement) {
+	assertNoError(d.tx.Create(a))
+}
+
+func (d groupDao) GetAnnouncements(groupId uint64, lastId uint64, limit int) []*entity.GroupAnnouncement {
+	var list []*entity.GroupAnnouncement
+	tx := d.tx.Where("group_id = ?", groupId).Order("id DESC").Limit(limit)
+	if lastId != 0 {
+		tx = tx.Where("id < ?", lastId)
+	}
+	assertNoError(tx.Find(&list))
+	return list
+}
+
 func NewGroupDao(tx Tx) GroupDao {
 	return groupDao{tx: tx}
 }
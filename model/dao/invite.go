@@ -0,0 +1,119 @@
+package dao
+
+import (
+	"gorm.io/gorm"
+	"ichat-go/model/entity"
+	"time"
+)
+
+type GroupInviteDao interface {
+	CreateInvite(e *entity.GroupInvite)
+	FindInviteById(id uint64) *entity.GroupInvite
+	GetActiveInvites(groupId uint64) []*entity.GroupInvite
+	RevokeInvite(id uint64)
+	UseInvite(id uint64) bool
+	IncreaseInviteUses(id uint64)
+	CreateJoinRequest(e *entity.GroupJoinRequest)
+	FindJoinRequestById(id uint64) *entity.GroupJoinRequest
+	FindPendingJoinRequest(groupId uint64, userId uint64) *entity.GroupJoinRequest
+	GetPendingJoinRequests(groupId uint64) []*entity.GroupJoinRequest
+	UpdateJoinRequestStatus(id uint64, handlerId uint64, status int) bool
+}
+
+type groupInviteDao struct {
+	tx Tx
+}
+
+func (d groupInviteDao) CreateInvite(e *entity.GroupInvite) {
+	assertNoError(d.tx.Create(e))
+}
+
+func (d groupInviteDao) FindInviteById(id uint64) *entity.GroupInvite {
+	var invite entity.GroupInvite
+	tx := d.tx.First(&invite, id)
+	if checkIsEmpty(tx) {
+		return nil
+	}
+	return &invite
+}
+
+func (d groupInviteDao) GetActiveInvites(groupId uint64) []*entity.GroupInvite {
+	var invites []*entity.GroupInvite
+	tx := d.tx.Where("group_id = ? and revoked = ?", groupId, false).
+		Where("expire_at is null or expire_at > ?", time.Now()).
+		Where("max_uses = 0 or uses < max_uses").
+		Order("id DESC").
+		Find(&invites)
+	assertNoError(tx)
+	return invites
+}
+
+func (d groupInviteDao) RevokeInvite(id uint64) {
+	tx := d.tx.Model(&entity.GroupInvite{}).Where("id = ?", id).Update("revoked", true)
+	assertNoError(tx)
+}
+
+// UseInvite 邀请链接有效时使用次数加一，返回是否成功
+func (d groupInviteDao) UseInvite(id uint64) bool {
+	tx := d.tx.Model(&entity.GroupInvite{}).
+		Where("id = ? and revoked = ?", id, false).
+		Where("expire_at is null or expire_at > ?", time.Now()).
+		Where("max_uses = 0 or uses < max_uses").
+		UpdateColumn("uses", gorm.Expr("uses + 1"))
+	return rowsAffected(tx) > 0
+}
+
+// IncreaseInviteUses 使用次数加一，不校验邀请链接当前是否有效
+func (d groupInviteDao) IncreaseInviteUses(id uint64) {
+	tx := d.tx.Model(&entity.GroupInvite{}).
+		Where("id = ?", id).
+		UpdateColumn("uses", gorm.Expr("uses + 1"))
+	assertNoError(tx)
+}
+
+func (d groupInviteDao) CreateJoinRequest(e *entity.GroupJoinRequest) {
+	assertNoError(d.tx.Create(e))
+}
+
+func (d groupInviteDao) FindJoinRequestById(id uint64) *entity.GroupJoinRequest {
+	var request entity.GroupJoinRequest
+	tx := d.tx.First(&request, id)
+	if checkIsEmpty(tx) {
+		return nil
+	}
+	return &request
+}
+
+func (d groupInviteDao) FindPendingJoinRequest(groupId uint64, userId uint64) *entity.GroupJoinRequest {
+	var request entity.GroupJoinRequest
+	tx := d.tx.First(&request, "group_id = ? and user_id = ? and status = ?",
+		groupId, userId, entity.GroupJoinRequestStatusPending)
+	if checkIsEmpty(tx) {
+		return nil
+	}
+	return &request
+}
+
+func (d groupInviteDao) GetPendingJoinRequests(groupId uint64) []*entity.GroupJoinRequest {
+	var requests []*entity.GroupJoinRequest
+	tx := d.tx.Where("group_id = ? and status = ?", groupId, entity.GroupJoinRequestStatusPending).
+		Order("id DESC").
+		Find(&requests)
+	assertNoError(tx)
+	return requests
+}
+
+// UpdateJoinRequestStatus 只处理待审核的申请，返回是否成功
+func (d groupInviteDao) UpdateJoinRequestStatus(id uint64, handlerId uint64, status int) bool {
+	tx := d.tx.Model(&entity.GroupJoinRequest{}).
+		Where("id = ? and status = ?", id, entity.GroupJoinRequestStatusPending).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"handler_id": handlerId,
+		})
+	return rowsAffected(tx) > 0
+}
+
+func NewGroupInviteDao(tx Tx) GroupInviteDao {
+	return groupInviteDao{tx: tx}
+}
@@ -0,0 +1,82 @@
+package dao
+
+import (
+	"ichat-go/model/entity"
+)
+
+type ScheduledMessageDao interface {
+	CreateScheduledMessage(e *entity.ScheduledMessage)
+	FindScheduledMessageById(id uint64) *entity.ScheduledMessage
+	GetPendingScheduledMessages(userId uint64, contactId uint64) []*entity.ScheduledMessage
+	GetAllPendingScheduledMessages() []*entity.ScheduledMessage
+	UpdatePendingScheduledMessage(e *entity.ScheduledMessage) bool
+	UpdateScheduledMessageStatus(id uint64, from int, to int) bool
+	SetScheduledMessageSent(id uint64, messageId uint64)
+}
+
+type scheduledMessageDao struct {
+	tx Tx
+}
+
+func (d scheduledMessageDao) CreateScheduledMessage(e *entity.ScheduledMessage) {
+	assertNoError(d.tx.Create(e))
+}
+
+func (d scheduledMessageDao) FindScheduledMessageById(id uint64) *entity.ScheduledMessage {
+	var e entity.ScheduledMessage
+	tx := d.tx.First(&e, id)
+	if checkIsEmpty(tx) {
+		return nil
+	}
+	return &e
+}
+
+// GetPendingScheduledMessages contactId为0时查询所有联系人
+func (d scheduledMessageDao) GetPendingScheduledMessages(userId uint64, contactId uint64) []*entity.ScheduledMessage {
+	var list []*entity.ScheduledMessage
+	tx := d.tx.Where("user_id = ? and status = ?", userId, entity.ScheduledMessageStatusPending)
+	if contactId != 0 {
+		tx = tx.Where("contact_id = ?", contactId)
+	}
+	assertNoError(tx.Order("send_at ASC").Find(&list))
+	return list
+}
+
+func (d scheduledMessageDao) GetAllPendingScheduledMessages() []*entity.ScheduledMessage {
+	var list []*entity.ScheduledMessage
+	tx := d.tx.Select("id, send_at").
+		Where("status = ?", entity.ScheduledMessageStatusPending).
+		Find(&list)
+	assertNoError(tx)
+	return list
+}
+
+// UpdatePendingScheduledMessage 只更新待发送的定时消息，返回是否更新成功
+func (d scheduledMessageDao) UpdatePendingScheduledMessage(e *entity.ScheduledMessage) bool {
+	tx := d.tx.Model(&entity.ScheduledMessage{}).
+		Where("id = ? and status = ?", e.Id, entity.ScheduledMessageStatusPending).
+		Updates(map[string]interface{}{
+			"message": e.Message,
+			"send_at": e.SendAt,
+		})
+	return rowsAffected(tx) > 0
+}
+
+// UpdateScheduledMessageStatus 状态为from时才更新，用于保证多实例下只处理一次
+func (d scheduledMessageDao) UpdateScheduledMessageStatus(id uint64, from int, to int) bool {
+	tx := d.tx.Model(&entity.ScheduledMessage{}).
+		Where("id = ? and status = ?", id, from).
+		Update("status", to)
+	return rowsAffected(tx) > 0
+}
+
+func (d scheduledMessageDao) SetScheduledMessageSent(id uint64, messageId uint64) {
+	tx := d.tx.Model(&entity.ScheduledMessage{}).
+		Where("id = ?", id).
+		Update("message_id", messageId)
+	assertNoError(tx)
+}
+
+func NewScheduledMessageDao(tx Tx) ScheduledMessageDao {
+	return scheduledMessageDao{tx: tx}
+}
@@ -0,0 +1,80 @@
+package dao
+
+import (
+	"ichat-go/model/entity"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageIndex 消息全文索引
+type MessageIndex interface {
+	Index(m *entity.ChatMessage)
+	Remove(messageId uint64)
+	Search(q *MessageQuery) []*entity.ChatMessage
+}
+
+type MessageQuery struct {
+	OwnerId       uint64 // 只搜索该用户有联系人的聊天室
+	Keyword       string
+	RoomId        uint64
+	SenderId      uint64
+	StartTime     *time.Time
+	EndTime       *time.Time
+	LastMessageId uint64
+	Limit         int
+}
+
+// ngram_token_size默认为2，更短的关键词无法命中全文索引
+const ngramTokenSize = 2
+
+// mysqlMessageIndex 基于MySQL FULLTEXT(ngram parser)，索引由MySQL随chat_messages.text自动维护
+type mysqlMessageIndex struct {
+	tx Tx
+}
+
+func (d mysqlMessageIndex) Index(_ *entity.ChatMessage) {
+}
+
+func (d mysqlMessageIndex) Remove(_ uint64) {
+}
+
+func escapeLike(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s)
+}
+
+func (d mysqlMessageIndex) Search(q *MessageQuery) []*entity.ChatMessage {
+	tx := visibleTo(d.tx.Model(&entity.ChatMessage{}), q.OwnerId).
+		Where("type = ? and revoked = ?", entity.ChatMessageTypeText, false).
+		// 已删除的好友不再搜索，已解散的群仍可搜索
+		Where("room_id in (select room_id from contacts where owner_id = ? and status != ?)",
+			q.OwnerId, entity.ContactStatusRemoved).
+		Where("text like ?", "%"+escapeLike(q.Keyword)+"%")
+	if utf8.RuneCountInString(q.Keyword) >= ngramTokenSize {
+		phrase := `"` + strings.ReplaceAll(q.Keyword, `"`, " ") + `"`
+		tx = tx.Where("match(text) against (? in boolean mode)", phrase)
+	}
+	if q.RoomId != 0 {
+		tx = tx.Where("room_id = ?", q.RoomId)
+	}
+	if q.SenderId != 0 {
+		tx = tx.Where("sender_id = ?", q.SenderId)
+	}
+	if q.StartTime != nil {
+		tx = tx.Where("created_at >= ?", q.StartTime)
+	}
+	if q.EndTime != nil {
+		tx = tx.Where("created_at < ?", q.EndTime)
+	}
+	if q.LastMessageId != 0 {
+		tx = tx.Where("message_id < ?", q.LastMessageId)
+	}
+	var messages []*entity.ChatMessage
+	assertNoError(tx.Order("message_id DESC").Limit(q.Limit).Find(&messages))
+	return messages
+}
+
+func NewMysqlMessageIndex(tx Tx) MessageIndex {
+	return mysqlMessageIndex{tx: tx}
+}
@@ -3,16 +3,140 @@ package dto
 import "ichat-go/model/entity"
 
 type SendMessageDto struct {
+	ContactId  uint64   `json:"contactId"`
+	LocalId    string   `json:"localId"`
+	Text       string   `json:"text" validate:"omitempty,max=5000"`
+	Image      string   `json:"image" validate:"omitempty,url"`
+	Thumbnail  string   `json:"thumbnail" validate:"omitempty"`
+	File       string   `json:"file" validate:"omitempty,url"`
+	FileName   string   `json:"fileName" validate:"omitempty,max=255"`
+	FileSize   int64    `json:"fileSize" validate:"omitempty,min=0"`
+	MimeType   string   `json:"mimeType" validate:"omitempty,max=127"`
+	Audio      string   `json:"audio" validate:"omitempty,url"`
+	Duration   int      `json:"duration" validate:"omitempty,min=0,max=300000"`
+	Waveform   []int    `json:"waveform" validate:"max=128,dive,min=0,max=255"`
+	ReplyToId  uint64   `json:"replyToId" validate:"omitempty"`
+	MentionIds []uint64 `json:"mentionIds" validate:"max=100"`
+	MentionAll bool     `json:"mentionAll"`
+}
+
+// ScheduleMessageDto SendAt为毫秒时间戳
+type ScheduleMessageDto struct {
+	SendMessageDto
+	SendAt int64 `json:"sendAt" validate:"required"`
+}
+
+type EditScheduledMessageDto struct {
+	Id uint64 `json:"id"`
+	ScheduleMessageDto
+}
+
+// MessageTTLDto TTL为消息自动删除时间，单位秒，0表示关闭
+type MessageTTLDto struct {
 	ContactId uint64 `json:"contactId"`
-	LocalId   string `json:"localId"`
-	Text      string `json:"text" validate:"omitempty"`
-	Image     string `json:"image" validate:"omitempty,url"`
-	Thumbnail string `json:"thumbnail" validate:"omitempty"`
+	TTL       int    `json:"ttl" validate:"min=0,max=2592000"`
+}
+
+type HideMessagesDto struct {
+	ContactId  uint64   `json:"contactId"`
+	MessageIds []uint64 `json:"messageIds" validate:"min=1,max=100"`
+}
+
+// MessagesHiddenDto 删除消息通知，ClearedMessageId不为0时表示清空了该消息及之前的聊天记录
+type MessagesHiddenDto struct {
+	ContactId        uint64   `json:"contactId"`
+	MessageIds       []uint64 `json:"messageIds"`
+	ClearedMessageId uint64   `json:"clearedMessageId"`
+}
+
+// RecordMessagesDto 查询聊天记录详情，Path为打开嵌套的聊天记录时从最外层到上一层的聊天记录消息id
+type RecordMessagesDto struct {
+	MessageId uint64   `form:"messageId"`
+	Path      []uint64 `form:"path" validate:"max=10"`
+}
+
+type PinnedMessageDto struct {
+	entity.PinnedMessage
+	Message *ChatMessageDto `json:"message"`
+}
+
+// PinsUpdatedDto 置顶消息更新通知，Pins为最新的置顶列表
+type PinsUpdatedDto struct {
+	RoomId uint64              `json:"roomId"`
+	Pins   []*PinnedMessageDto `json:"pins"`
+}
+
+type EditMessageDto struct {
+	MessageId uint64 `json:"messageId"`
+	Text      string `json:"text" validate:"required,max=5000"`
+}
+
+type ReactMessageDto struct {
+	MessageId uint64 `json:"messageId"`
+	Emoji     string `json:"emoji" validate:"required,max=32"`
+}
+
+type ReactionCountDto struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+type ReactionDto struct {
+	ReactionCountDto
+	UserIds []uint64 `json:"userIds"`
+}
+
+// MessageReactionDto 表情回应变更通知，Emoji为空表示取消回应
+type MessageReactionDto struct {
+	RoomId    uint64              `json:"roomId"`
+	MessageId uint64              `json:"messageId"`
+	UserId    uint64              `json:"userId"`
+	Emoji     string              `json:"emoji"`
+	Reactions []*ReactionCountDto `json:"reactions"`
+}
+
+type MarkReadDto struct {
+	ContactId uint64 `json:"contactId"`
+	MessageId uint64 `json:"messageId" validate:"omitempty"`
 }
 
+type AckReceivedDto struct {
+	LastDeliveryId uint64 `json:"lastDeliveryId" form:"lastDeliveryId"`
+}
+
+// ReceiptDto 回执通知，表示UserId已接收或已读RoomId中MessageId及之前的消息，已播放仅针对MessageId这一条
+type ReceiptDto struct {
+	RoomId    uint64 `json:"roomId"`
+	UserId    uint64 `json:"userId"`
+	MessageId uint64 `json:"messageId"`
+	Status    int    `json:"status"`
+}
+
+type TypingDto struct {
+	ContactId uint64 `json:"contactId"`
+	Typing    bool   `json:"-"`
+}
+
+// TypingNotificationDto 正在输入通知，只推送给在线会话，ExpireAt(毫秒时间戳)后客户端应自动清除状态
+type TypingNotificationDto struct {
+	RoomId   uint64 `json:"roomId"`
+	UserId   uint64 `json:"userId"`
+	Typing   bool   `json:"typing"`
+	ExpireAt int64  `json:"expireAt"`
+}
+
+// ForwardMessagesDto Merged为true时合并为一条聊天记录消息转发
+type ForwardMessagesDto struct {
+	MessageIds []uint64 `json:"messageIds" validate:"min=1,max=100"`
+	ContactIds []uint64 `json:"contactIds" validate:"min=1,max=20"`
+	Merged     bool     `json:"merged"`
+}
+
+// DelayUploadDto 图片消息填Image，文件消息填File
 type DelayUploadDto struct {
 	MessageId uint64 `json:"messageId"`
-	Image     string `json:"image" validate:"url"`
+	Image     string `json:"image" validate:"omitempty,url"`
+	File      string `json:"file" validate:"omitempty,url"`
 }
 
 type QueryChatMessageDto struct {
@@ -26,11 +150,47 @@ type CallDto struct {
 	Handled bool `json:"handled"`
 }
 
+// ReplyMessageDto 被引用消息的快照
+type ReplyMessageDto struct {
+	MessageId uint64 `json:"messageId"`
+	SenderId  uint64 `json:"senderId"`
+	Type      int    `json:"type"`
+	Preview   string `json:"preview"`
+	Revoked   bool   `json:"revoked"`
+}
+
+// ReplyUpdatedDto 被引用消息撤回、编辑后的快照更新通知，MessageIds为引用了该消息的消息
+type ReplyUpdatedDto struct {
+	RoomId     uint64           `json:"roomId"`
+	MessageIds []uint64         `json:"messageIds"`
+	Reply      *ReplyMessageDto `json:"reply"`
+}
+
 type ChatMessageDto struct {
 	entity.ChatMessage
-	Call       *CallDto `json:"call"`
-	LocalId    string   `json:"localId"`
-	DeliveryId uint64   `json:"deliveryId"`
+	Call       *CallDto            `json:"call"`
+	Reply      *ReplyMessageDto    `json:"reply"`
+	Reactions  []*ReactionCountDto `json:"reactions"`
+	LocalId    string              `json:"localId"`
+	DeliveryId uint64              `json:"deliveryId"`
+}
+
+// SearchMessageDto 时间为毫秒时间戳
+type SearchMessageDto struct {
+	Keyword       string `form:"keyword" validate:"required,max=50"`
+	ContactId     uint64 `form:"contactId" validate:"omitempty"`
+	SenderId      uint64 `form:"senderId" validate:"omitempty"`
+	StartTime     int64  `form:"startTime" validate:"omitempty"`
+	EndTime       int64  `form:"endTime" validate:"omitempty"`
+	LastMessageId uint64 `form:"lastMessageId" validate:"omitempty"`
+	Limit         int    `form:"limit" validate:"omitempty,max=50"`
+}
+
+// SearchMessageItem Highlights为关键词在文本中的位置，按字符计算的[start, end)
+type SearchMessageItem struct {
+	ChatMessageDto
+	ContactId  uint64   `json:"contactId"`
+	Highlights [][2]int `json:"highlights"`
 }
 
 type NotificationMessageDto struct {
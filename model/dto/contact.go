@@ -9,3 +9,27 @@ type AddUserContactDto struct {
 }
 
 type ContactDto = entity.Contact
+
+// DeleteContactDto ClearHistory为true时同时清空自己的聊天记录
+type DeleteContactDto struct {
+	ContactId    uint64 `json:"contactId"`
+	ClearHistory bool   `json:"clearHistory"`
+}
+
+type ContactRemovedDto struct {
+	ContactId uint64 `json:"contactId"`
+	RoomId    uint64 `json:"roomId"`
+}
+
+type ContactStatusDto struct {
+	ContactId uint64 `json:"contactId"`
+	RoomId    uint64 `json:"roomId"`
+	Status    int    `json:"status"`
+}
+
+type ContactUnreadDto struct {
+	ContactId         uint64 `json:"contactId"`
+	LastReadMessageId uint64 `json:"lastReadMessageId"`
+	UnreadCount       int    `json:"unreadCount"`
+	MentionCount      int    `json:"mentionCount"`
+}
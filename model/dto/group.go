@@ -1,7 +1,86 @@
 package dto
 
+import "ichat-go/model/entity"
+
 type CreateGroupDto struct {
 	Name       string   `json:"name"`
 	Avatar     string   `json:"avatar" validate:"omitempty,url"`
 	ContactIds []uint64 `json:"contactIds" validate:"min=1,max=100"`
 }
+
+type GroupMembersDto struct {
+	GroupId    uint64   `json:"groupId"`
+	ContactIds []uint64 `json:"contactIds" validate:"min=1,max=100"`
+}
+
+type GroupMemberDto struct {
+	GroupId uint64 `json:"groupId"`
+	UserId  uint64 `json:"userId"`
+}
+
+type RemoveGroupMembersDto struct {
+	GroupId uint64   `json:"groupId"`
+	UserIds []uint64 `json:"userIds" validate:"min=1,max=100"`
+}
+
+// GroupProfileDto 为空的字段不修改，Announcement为空字符串时清空群公告
+type GroupProfileDto struct {
+	GroupId      uint64  `json:"groupId"`
+	Name         *string `json:"name" validate:"omitempty,min=1,max=50"`
+	Avatar       *string `json:"avatar" validate:"omitempty,url"`
+	Announcement *string `json:"announcement" validate:"omitempty,max=2000"`
+}
+
+type QueryAnnouncementsDto struct {
+	GroupId uint64 `form:"groupId"`
+	LastId  uint64 `form:"lastId" validate:"omitempty"`
+	Limit   int    `form:"limit" validate:"omitempty,max=50"`
+}
+
+// GroupSettingsDto 为空的字段不修改
+type GroupSettingsDto struct {
+	GroupId      uint64 `json:"groupId"`
+	MemberCanPin *bool  `json:"memberCanPin"`
+}
+
+type MuteAllDto struct {
+	GroupId uint64 `json:"groupId"`
+	Mute    bool   `json:"mute"`
+}
+
+// MuteMemberDto Duration为禁言时长(秒)，为0时解除禁言
+type MuteMemberDto struct {
+	GroupId  uint64 `json:"groupId"`
+	UserId   uint64 `json:"userId"`
+	Duration int    `json:"duration" validate:"omitempty,min=60,max=2592000"`
+}
+
+// CreateGroupInviteDto ExpireIn为有效时长(秒)，为0时永不过期；MaxUses为0时不限次数
+type CreateGroupInviteDto struct {
+	GroupId         uint64 `json:"groupId"`
+	ExpireIn        int    `json:"expireIn" validate:"omitempty,min=60,max=31536000"`
+	MaxUses         int    `json:"maxUses" validate:"omitempty,min=1,max=500"`
+	RequireApproval bool   `json:"requireApproval"`
+}
+
+type GroupInviteDto struct {
+	*entity.GroupInvite
+	Code string `json:"code"`
+}
+
+type GroupInviteCodeDto struct {
+	Code string `json:"code" form:"code" validate:"min=1"`
+}
+
+// GroupInviteInfoDto 通过邀请链接入群前展示的群信息
+type GroupInviteInfoDto struct {
+	Group           *entity.Group `json:"group"`
+	MemberCount     int           `json:"memberCount"`
+	RequireApproval bool          `json:"requireApproval"`
+}
+
+// JoinGroupResultDto Pending为true时表示已提交入群申请，等待管理员审核
+type JoinGroupResultDto struct {
+	GroupId uint64 `json:"groupId"`
+	Pending bool   `json:"pending"`
+}
@@ -17,6 +17,7 @@ const (
 	CallEndReasonLostConnection = 5
 	CallEndReasonError          = 6
 	CallEndReasonCancelled      = 7
+	CallEndReasonClosed         = 8 // 聊天已关闭，如群聊解散
 )
 
 type Call struct {
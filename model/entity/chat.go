@@ -1,45 +1,169 @@
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ChatMessageTypeText   = 1
+	ChatMessageTypeImage  = 2
+	ChatMessageTypeCall   = 3
+	ChatMessageTypeRecord = 4
+	ChatMessageTypeFile   = 5
+	ChatMessageTypeAudio  = 6
+	ChatMessageTypeSystem = 7
+)
+
+// 系统消息事件
+const (
+	SystemEventMessageTTL       = 1 // 修改消息自动删除时间
+	SystemEventMessagePinned    = 2
+	SystemEventMessageUnpinned  = 3
+	SystemEventGroupCreated     = 4
+	SystemEventMembersAdded     = 5
+	SystemEventMembersRemoved   = 6
+	SystemEventMemberLeft       = 7
+	SystemEventGroupRenamed     = 8
+	SystemEventAdminAdded       = 9
+	SystemEventAdminRemoved     = 10
+	SystemEventOwnerTransferred = 11
+	SystemEventGroupAvatar      = 12
+	SystemEventAnnouncement     = 13
+	SystemEventMemberJoined     = 14 // 通过邀请链接入群
+	SystemEventGroupDissolved   = 15
+	SystemEventMuteAllEnabled   = 16
+	SystemEventMuteAllDisabled  = 17
+	SystemEventMemberMuted      = 18
+	SystemEventMemberUnmuted    = 19 // UserIds为空表示禁言到期自动解除
+)
 
 const (
-	ChatMessageTypeText  = 1
-	ChatMessageTypeImage = 2
-	ChatMessageTypeCall  = 3
+	ScheduledMessageStatusPending  = 1
+	ScheduledMessageStatusSent     = 2
+	ScheduledMessageStatusCanceled = 3
+	ScheduledMessageStatusFailed   = 4
 )
 
 const (
 	MessageDeliveryStatusSending  = 1
 	MessageDeliveryStatusReceived = 2
 	MessageDeliveryStatusRead     = 3
+	MessageDeliveryStatusPlayed   = 4 // 语音消息已播放
+)
+
+const (
+	ChatRoomTypeUser  = 1
+	ChatRoomTypeGroup = 2
 )
 
+// ChatRoom 聊天室，成员见RoomMember；Name只用于用户聊天室去重，格式为u-小uid-大uid
 type ChatRoom struct {
 	RoomId    uint64    `json:"roomId" gorm:"primaryKey"`
-	Name      string    `json:"name"`
+	Type      int       `json:"type"`
+	Name      string    `json:"name" gorm:"default:null"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
-type ChatMessage struct {
-	MessageId uint64    `json:"messageId" gorm:"primaryKey"`
+// RoomMember 聊天室成员，消息投递给聊天室的所有成员
+type RoomMember struct {
+	Id        uint64    `json:"id" gorm:"primaryKey"`
 	RoomId    uint64    `json:"roomId"`
+	UserId    uint64    `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ChatMessage struct {
+	MessageId     uint64         `json:"messageId" gorm:"primaryKey"`
+	RoomId        uint64         `json:"roomId"`
+	SenderId      uint64         `json:"senderId"`
+	Type          int            `json:"type"`
+	Text          string         `json:"text"`
+	Image         string         `json:"image"`
+	Thumbnail     string         `json:"thumbnail"`
+	File          string         `json:"file"`
+	FileName      string         `json:"fileName"`
+	FileSize      int64          `json:"fileSize"`
+	MimeType      string         `json:"mimeType"`
+	Audio         string         `json:"audio"`
+	Duration      int            `json:"duration"`
+	Waveform      []int          `json:"waveform" gorm:"serializer:json"`
+	CallId        uint64         `json:"callId"`
+	ReplyToId     uint64         `json:"replyToId"`
+	Mentions      []uint64       `json:"mentions" gorm:"serializer:json"`
+	MentionAll    bool           `json:"mentionAll"`
+	ForwardedFrom uint64         `json:"forwardedFrom"`
+	Record        *MessageRecord `json:"record" gorm:"serializer:json"`
+	System        *SystemMessage `json:"system" gorm:"serializer:json"`
+	Revoked       bool           `json:"revoked"`
+	Expired       bool           `json:"expired"`
+	ExpireAt      *time.Time     `json:"expireAt"`
+	EditedAt      *time.Time     `json:"editedAt"`
+	CreatedAt     time.Time      `json:"createdAt"`
+	UpdatedAt     time.Time      `json:"updatedAt"`
+}
+
+// MessageRecord 合并转发的聊天记录，Items为卡片预览
+type MessageRecord struct {
+	MessageIds []uint64             `json:"messageIds"`
+	Items      []*MessageRecordItem `json:"items"`
+}
+
+type MessageRecordItem struct {
 	SenderId  uint64    `json:"senderId"`
 	Type      int       `json:"type"`
+	Preview   string    `json:"preview"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SystemMessage 系统消息内容，操作者为消息的发送者
+type SystemMessage struct {
+	Event     int      `json:"event"`
+	UserIds   []uint64 `json:"userIds,omitempty"`
+	Duration  int      `json:"duration,omitempty"` // 秒
+	MessageId uint64   `json:"messageId,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	Text      string   `json:"text,omitempty"`
+}
+
+type ChatMessageEdit struct {
+	Id        uint64    `json:"id" gorm:"primaryKey"`
+	MessageId uint64    `json:"messageId"`
 	Text      string    `json:"text"`
-	Image     string    `json:"image"`
-	Thumbnail string    `json:"thumbnail"`
-	CallId    uint64    `json:"callId"`
-	Revoked   bool      `json:"revoked"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type MessageReaction struct {
+	MessageId uint64    `json:"messageId" gorm:"primaryKey"`
+	UserId    uint64    `json:"userId" gorm:"primaryKey"`
+	Emoji     string    `json:"emoji"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
 
+// PinnedMessage 聊天室置顶消息，UserId为置顶操作者
+type PinnedMessage struct {
+	Id        uint64    `json:"id" gorm:"primaryKey"`
+	RoomId    uint64    `json:"roomId"`
+	MessageId uint64    `json:"messageId"`
+	UserId    uint64    `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MessageHide 用户删除(仅自己不可见)的消息
+type MessageHide struct {
+	UserId    uint64    `json:"userId" gorm:"primaryKey"`
+	MessageId uint64    `json:"messageId" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
 type MessageDelivery struct {
 	Id         uint64    `json:"id" gorm:"primaryKey"`
 	MessageId  uint64    `json:"messageId"`
 	ReceiverId uint64    `json:"receiverId"`
 	Status     int       `json:"status"`
+	Mentioned  bool      `json:"mentioned"`
 	CreatedAt  time.Time `json:"createdAt"`
 	UpdatedAt  time.Time `json:"updatedAt"`
 }
@@ -47,3 +171,16 @@ type MessageDelivery struct {
 func (*MessageDelivery) TableName() string {
 	return "message_deliveries"
 }
+
+// ScheduledMessage 定时消息，Message为发送消息的参数
+type ScheduledMessage struct {
+	Id        uint64          `json:"id" gorm:"primaryKey"`
+	UserId    uint64          `json:"userId"`
+	ContactId uint64          `json:"contactId"`
+	Message   json.RawMessage `json:"message"`
+	SendAt    time.Time       `json:"sendAt"`
+	Status    int             `json:"status"`
+	MessageId uint64          `json:"messageId"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
@@ -3,7 +3,9 @@ package entity
 import "time"
 
 const (
-	ContactStatusNormal = 1
+	ContactStatusNormal   = 1
+	ContactStatusReadOnly = 2 // 只能查看聊天记录，不能发送消息(群已解散)
+	ContactStatusRemoved  = 3 // 已删除好友，保留聊天记录，重新添加好友后恢复
 )
 
 const (
@@ -23,6 +25,11 @@ type Contact struct {
 	LastMessageId      uint64     `json:"lastMessageId" gorm:"column:last_msg_id"`
 	LastMessageTime    *time.Time `json:"lastMessageTime" gorm:"column:last_msg_time"`
 	LastMessageContent string     `json:"lastMessageContent" gorm:"column:last_msg_content"`
+	LastReadMessageId  uint64     `json:"lastReadMessageId" gorm:"column:last_read_msg_id"`
+	ClearedMessageId   uint64     `json:"clearedMessageId" gorm:"column:cleared_msg_id"`
+	UnreadCount        int        `json:"unreadCount"`
+	MentionCount       int        `json:"mentionCount"`
+	MessageTTL         int        `json:"messageTTL" gorm:"column:message_ttl"`
 	CreatedAt          time.Time  `json:"createdAt"`
 	UpdatedAt          time.Time  `json:"updatedAt"`
 }
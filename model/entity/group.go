@@ -3,23 +3,78 @@ package entity
 import "time"
 
 type Group struct {
-	GroupId   uint64    `json:"groupId" gorm:"primaryKey"`
-	OwnerId   uint64    `json:"ownerId"`
-	RoomId    uint64    `json:"roomId"`
-	Name      string    `json:"name"`
-	Avatar    string    `json:"avatar"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	GroupId      uint64 `json:"groupId" gorm:"primaryKey"`
+	OwnerId      uint64 `json:"ownerId"`
+	RoomId       uint64 `json:"roomId"`
+	Name         string `json:"name"`
+	Avatar       string `json:"avatar"`
+	MemberCanPin bool   `json:"memberCanPin"` // 普通成员是否可以置顶消息
+	Dissolved    bool   `json:"dissolved"`
+	MuteAll      bool   `json:"muteAll"` // 全员禁言，群主和管理员除外
+	// 当前群公告，历史记录见GroupAnnouncement
+	Announcement   string     `json:"announcement"`
+	AnnouncementBy uint64     `json:"announcementBy"`
+	AnnouncementAt *time.Time `json:"announcementAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
 }
 
 func (g *Group) TableName() string {
 	return "chat_groups"
 }
 
+const (
+	GroupJoinRequestStatusPending  = 1
+	GroupJoinRequestStatusAccepted = 2
+	GroupJoinRequestStatusRejected = 3
+)
+
+const (
+	GroupRoleOwner  = 1
+	GroupRoleAdmin  = 2
+	GroupRoleMember = 3
+)
+
 type GroupMember struct {
+	Id         uint64     `json:"id" gorm:"primaryKey"`
+	GroupId    uint64     `json:"groupId"`
+	UserId     uint64     `json:"userId"`
+	Role       int        `json:"role"`
+	MutedUntil *time.Time `json:"mutedUntil"` // 禁言到期时间，为空表示未禁言
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+type GroupAnnouncement struct {
+	Id        uint64    `json:"id" gorm:"primaryKey"`
+	GroupId   uint64    `json:"groupId"`
+	UserId    uint64    `json:"userId"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// GroupInvite 群邀请链接，MaxUses为0表示不限次数，ExpireAt为空表示永不过期
+type GroupInvite struct {
+	Id              uint64     `json:"id" gorm:"primaryKey"`
+	GroupId         uint64     `json:"groupId"`
+	CreatorId       uint64     `json:"creatorId"`
+	ExpireAt        *time.Time `json:"expireAt"`
+	MaxUses         int        `json:"maxUses"`
+	Uses            int        `json:"uses"`
+	RequireApproval bool       `json:"requireApproval"`
+	Revoked         bool       `json:"revoked"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// GroupJoinRequest 通过需要审核的邀请链接提交的入群申请
+type GroupJoinRequest struct {
 	Id        uint64    `json:"id" gorm:"primaryKey"`
 	GroupId   uint64    `json:"groupId"`
+	InviteId  uint64    `json:"inviteId"`
 	UserId    uint64    `json:"userId"`
+	Status    int       `json:"status"`
+	HandlerId uint64    `json:"handlerId"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -34,9 +34,13 @@ type mq struct {
 	logger   logging.Logger
 }
 
+func mqStateKey(key string) string {
+	return "mq:" + key + ":state"
+}
+
 func NewMQ(key string) MQ {
 	m := &mq{c: di.ENV().RDB(), key: "mq:" + key}
-	m.stateKey = m.key + ":state"
+	m.stateKey = mqStateKey(key)
 	m.ctx, m.cancel = context.WithCancel(context.Background())
 	m.logger = logging.NewLogger(m.key)
 	m.lck = NewLock(m.key, time.Second*30)
@@ -58,6 +62,25 @@ func (m *mq) State() int {
 	return r
 }
 
+// States 批量查询多个队列的状态，使用pipeline减少网络往返
+func States(keys []string) []int {
+	states := make([]int, len(keys))
+	if len(keys) == 0 {
+		return states
+	}
+	c := di.ENV().RDB()
+	pipe := c.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Get(c.Context(), mqStateKey(key))
+	}
+	_, _ = pipe.Exec(c.Context())
+	for i, cmd := range cmds {
+		states[i], _ = cmd.Int()
+	}
+	return states
+}
+
 func (m *mq) Push(message Message) error {
 	_, err := m.c.RPush(m.ctx, m.key, toJson(message)).Result()
 	if err != nil && !errors.Is(err, context.Canceled) {
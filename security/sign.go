@@ -0,0 +1,19 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"ichat-go/config"
+)
+
+// Sign 使用JWT密钥对数据进行HMAC-SHA256签名，返回base64url编码的签名
+func Sign(data string) string {
+	mac := hmac.New(sha256.New, []byte(config.App.Jwt.Secret))
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func VerifySign(data string, sign string) bool {
+	return hmac.Equal([]byte(Sign(data)), []byte(sign))
+}
@@ -15,6 +15,7 @@ create table if not exists users
 create table if not exists chat_rooms
 (
     room_id    bigint auto_increment,
+    type       smallint    not null default 1,
     name       varchar(50) default null,
     created_at timestamp,
     updated_at timestamp,
@@ -22,30 +23,97 @@ create table if not exists chat_rooms
     unique (name)
 );
 
+create table if not exists room_members
+(
+    id         bigint auto_increment,
+    room_id    bigint not null,
+    user_id    bigint not null,
+    created_at timestamp,
+    primary key (id),
+    foreign key (room_id) references chat_rooms (room_id),
+    foreign key (user_id) references users (user_id),
+    unique (room_id, user_id),
+    key (user_id)
+);
+
 create table if not exists chat_groups
 (
     group_id   bigint auto_increment,
     owner_id   bigint      not null,
     room_id    bigint      not null,
-    name       varchar(50) not null,
-    avatar     text,
-    created_at timestamp,
-    updated_at timestamp,
+    name            varchar(50) not null,
+    avatar          text,
+    member_can_pin  bool        not null default false,
+    dissolved       bool        not null default false,
+    mute_all        bool        not null default false,
+    announcement    text,
+    announcement_by bigint,
+    announcement_at timestamp   null,
+    created_at      timestamp,
+    updated_at      timestamp,
     primary key (group_id),
     foreign key (owner_id) references users (user_id)
 );
 
 create table if not exists group_members
+(
+    id          bigint auto_increment,
+    group_id    bigint    not null,
+    user_id     bigint    not null,
+    role        smallint  not null default 3,
+    muted_until timestamp null,
+    created_at  timestamp,
+    updated_at  timestamp,
+    primary key (id),
+    foreign key (group_id) references chat_groups (group_id),
+    foreign key (user_id) references users (user_id),
+    unique (group_id, user_id)
+);
+
+create table if not exists group_announcements
 (
     id         bigint auto_increment,
     group_id   bigint not null,
     user_id    bigint not null,
+    content    text,
+    created_at timestamp,
+    primary key (id),
+    foreign key (group_id) references chat_groups (group_id),
+    foreign key (user_id) references users (user_id)
+);
+
+create table if not exists group_invites
+(
+    id               bigint auto_increment,
+    group_id         bigint not null,
+    creator_id       bigint not null,
+    expire_at        timestamp null,
+    max_uses         int    not null default 0,
+    uses             int    not null default 0,
+    require_approval bool   not null default false,
+    revoked          bool   not null default false,
+    created_at       timestamp,
+    updated_at       timestamp,
+    primary key (id),
+    foreign key (group_id) references chat_groups (group_id),
+    foreign key (creator_id) references users (user_id)
+);
+
+create table if not exists group_join_requests
+(
+    id         bigint auto_increment,
+    group_id   bigint   not null,
+    invite_id  bigint   not null,
+    user_id    bigint   not null,
+    status     smallint not null default 1,
+    handler_id bigint,
     created_at timestamp,
     updated_at timestamp,
     primary key (id),
+    key (group_id, status),
     foreign key (group_id) references chat_groups (group_id),
-    foreign key (user_id) references users (user_id),
-    unique (group_id, user_id)
+    foreign key (invite_id) references group_invites (id),
+    foreign key (user_id) references users (user_id)
 );
 
 create table if not exists contact_requests
@@ -73,6 +141,11 @@ create table if not exists contacts
     last_msg_id      bigint,
     last_msg_time    timestamp,
     last_msg_content varchar(100),
+    last_read_msg_id bigint,
+    cleared_msg_id   bigint,
+    unread_count     int      not null default 0,
+    mention_count    int      not null default 0,
+    message_ttl      int      not null default 0,
     created_at       timestamp,
     updated_at       timestamp,
     primary key (contact_id),
@@ -85,20 +158,59 @@ create table if not exists contacts
 
 create table if not exists chat_messages
 (
-    message_id bigint auto_increment,
-    room_id    bigint   not null,
-    sender_id  bigint   not null,
-    type       smallint not null default 0,
+    message_id     bigint auto_increment,
+    room_id        bigint   not null,
+    sender_id      bigint   not null,
+    type           smallint not null default 0,
+    text           text,
+    image          text,
+    thumbnail      text,
+    file           text,
+    file_name      varchar(255),
+    file_size      bigint,
+    mime_type      varchar(127),
+    audio          text,
+    duration       int,
+    waveform       text,
+    call_id        bigint,
+    reply_to_id    bigint,
+    mentions       text,
+    mention_all    bool              default false,
+    forwarded_from bigint,
+    record         text,
+    `system`       text,
+    revoked        bool              default false,
+    expired        bool              default false,
+    expire_at      timestamp null,
+    edited_at      timestamp null,
+    created_at     timestamp,
+    updated_at     timestamp,
+    primary key (message_id),
+    foreign key (room_id) references chat_rooms (room_id),
+    foreign key (sender_id) references users (user_id),
+    fulltext key ft_text (text) with parser ngram
+);
+
+create table if not exists chat_message_edits
+(
+    id         bigint auto_increment,
+    message_id bigint not null,
     text       text,
-    image      text,
-    thumbnail  text,
-    call_id    bigint,
-    revoked    bool              default false,
+    created_at timestamp,
+    primary key (id),
+    foreign key (message_id) references chat_messages (message_id)
+);
+
+create table if not exists message_reactions
+(
+    message_id bigint      not null,
+    user_id    bigint      not null,
+    emoji      varchar(32) not null,
     created_at timestamp,
     updated_at timestamp,
-    primary key (message_id),
-    foreign key (room_id) references chat_rooms (room_id),
-    foreign key (sender_id) references users (user_id)
+    primary key (message_id, user_id),
+    foreign key (message_id) references chat_messages (message_id),
+    foreign key (user_id) references users (user_id)
 );
 
 create table if not exists message_deliveries
@@ -107,6 +219,7 @@ create table if not exists message_deliveries
     message_id  bigint   not null,
     receiver_id bigint   not null,
     status      smallint not null default 0,
+    mentioned   bool     not null default false,
     created_at  timestamp,
     updated_at  timestamp,
     primary key (id),
@@ -114,6 +227,46 @@ create table if not exists message_deliveries
     foreign key (receiver_id) references users (user_id)
 );
 
+create table if not exists pinned_messages
+(
+    id         bigint auto_increment,
+    room_id    bigint not null,
+    message_id bigint not null,
+    user_id    bigint not null,
+    created_at timestamp,
+    primary key (id),
+    unique (room_id, message_id),
+    foreign key (room_id) references chat_rooms (room_id),
+    foreign key (message_id) references chat_messages (message_id),
+    foreign key (user_id) references users (user_id)
+);
+
+create table if not exists message_hides
+(
+    user_id    bigint not null,
+    message_id bigint not null,
+    created_at timestamp,
+    primary key (user_id, message_id),
+    foreign key (user_id) references users (user_id),
+    foreign key (message_id) references chat_messages (message_id)
+);
+
+create table if not exists scheduled_messages
+(
+    id         bigint auto_increment,
+    user_id    bigint    not null,
+    contact_id bigint    not null,
+    message    text      not null,
+    send_at    timestamp not null,
+    status     smallint  not null default 1,
+    message_id bigint,
+    created_at timestamp,
+    updated_at timestamp,
+    primary key (id),
+    key (user_id, status),
+    foreign key (user_id) references users (user_id)
+);
+
 create table if not exists calls
 (
     call_id    bigint auto_increment,
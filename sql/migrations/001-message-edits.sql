@@ -0,0 +1,14 @@
+-- 消息编辑及编辑历史
+
+alter table chat_messages
+    add column edited_at timestamp null after revoked;
+
+create table if not exists chat_message_edits
+(
+    id         bigint auto_increment,
+    message_id bigint not null,
+    text       text,
+    created_at timestamp,
+    primary key (id),
+    foreign key (message_id) references chat_messages (message_id)
+);
@@ -0,0 +1,4 @@
+-- 引用回复
+
+alter table chat_messages
+    add column reply_to_id bigint after call_id;
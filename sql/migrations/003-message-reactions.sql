@@ -0,0 +1,13 @@
+-- 消息表情回应
+
+create table if not exists message_reactions
+(
+    message_id bigint      not null,
+    user_id    bigint      not null,
+    emoji      varchar(32) not null,
+    created_at timestamp,
+    updated_at timestamp,
+    primary key (message_id, user_id),
+    foreign key (message_id) references chat_messages (message_id),
+    foreign key (user_id) references users (user_id)
+);
@@ -0,0 +1,5 @@
+-- 联系人未读数和已读位置，已有联系人从0开始计数
+
+alter table contacts
+    add column last_read_msg_id bigint after last_msg_content,
+    add column unread_count     int not null default 0 after last_read_msg_id;
@@ -0,0 +1,11 @@
+-- 群聊@提及
+
+alter table chat_messages
+    add column mentions    text after reply_to_id,
+    add column mention_all bool default false after mentions;
+
+alter table contacts
+    add column mention_count int not null default 0 after unread_count;
+
+alter table message_deliveries
+    add column mentioned bool not null default false after status;
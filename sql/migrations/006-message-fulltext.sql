@@ -0,0 +1,4 @@
+-- 聊天记录全文搜索，已有数据量大时建索引耗时较长
+
+alter table chat_messages
+    add fulltext key ft_text (text) with parser ngram;
@@ -0,0 +1,5 @@
+-- 消息转发、合并转发
+
+alter table chat_messages
+    add column forwarded_from bigint after mention_all,
+    add column record         text after forwarded_from;
@@ -0,0 +1,7 @@
+-- 文件消息
+
+alter table chat_messages
+    add column file      text after thumbnail,
+    add column file_name varchar(255) after file,
+    add column file_size bigint after file_name,
+    add column mime_type varchar(127) after file_size;
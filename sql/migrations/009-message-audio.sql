@@ -0,0 +1,6 @@
+-- 语音消息
+
+alter table chat_messages
+    add column audio    text after mime_type,
+    add column duration int after audio,
+    add column waveform text after duration;
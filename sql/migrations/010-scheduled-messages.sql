@@ -0,0 +1,17 @@
+-- 定时消息，联系人被删除时定时消息发送失败，contact_id不加外键
+
+create table if not exists scheduled_messages
+(
+    id         bigint auto_increment,
+    user_id    bigint    not null,
+    contact_id bigint    not null,
+    message    text      not null,
+    send_at    timestamp not null,
+    status     smallint  not null default 1,
+    message_id bigint,
+    created_at timestamp,
+    updated_at timestamp,
+    primary key (id),
+    key (user_id, status),
+    foreign key (user_id) references users (user_id)
+);
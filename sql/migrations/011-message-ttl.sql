@@ -0,0 +1,9 @@
+-- 消息定时自动删除，修改删除时间时发送系统消息
+
+alter table contacts
+    add column message_ttl int not null default 0 after mention_count;
+
+alter table chat_messages
+    add column `system`  text after record,
+    add column expired   bool default false after revoked,
+    add column expire_at timestamp null after expired;
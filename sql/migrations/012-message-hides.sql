@@ -0,0 +1,14 @@
+-- 删除消息(仅自己不可见)、清空聊天记录
+
+alter table contacts
+    add column cleared_msg_id bigint after last_read_msg_id;
+
+create table if not exists message_hides
+(
+    user_id    bigint not null,
+    message_id bigint not null,
+    created_at timestamp,
+    primary key (user_id, message_id),
+    foreign key (user_id) references users (user_id),
+    foreign key (message_id) references chat_messages (message_id)
+);
@@ -0,0 +1,18 @@
+-- 消息置顶
+
+alter table chat_groups
+    add column member_can_pin bool not null default false after avatar;
+
+create table if not exists pinned_messages
+(
+    id         bigint auto_increment,
+    room_id    bigint not null,
+    message_id bigint not null,
+    user_id    bigint not null,
+    created_at timestamp,
+    primary key (id),
+    unique (room_id, message_id),
+    foreign key (room_id) references chat_rooms (room_id),
+    foreign key (message_id) references chat_messages (message_id),
+    foreign key (user_id) references users (user_id)
+);
@@ -0,0 +1,9 @@
+-- 群成员角色，回填已有群的群主
+
+alter table group_members
+    add column role smallint not null default 3 after user_id;
+
+update group_members
+    join chat_groups on chat_groups.group_id = group_members.group_id
+set group_members.role = 1
+where group_members.user_id = chat_groups.owner_id;
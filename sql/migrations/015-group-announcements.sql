@@ -0,0 +1,18 @@
+-- 群公告
+
+alter table chat_groups
+    add column announcement    text after member_can_pin,
+    add column announcement_by bigint after announcement,
+    add column announcement_at timestamp null after announcement_by;
+
+create table if not exists group_announcements
+(
+    id         bigint auto_increment,
+    group_id   bigint not null,
+    user_id    bigint not null,
+    content    text,
+    created_at timestamp,
+    primary key (id),
+    foreign key (group_id) references chat_groups (group_id),
+    foreign key (user_id) references users (user_id)
+);
@@ -0,0 +1,35 @@
+-- 群邀请链接、入群申请
+
+create table if not exists group_invites
+(
+    id               bigint auto_increment,
+    group_id         bigint not null,
+    creator_id       bigint not null,
+    expire_at        timestamp null,
+    max_uses         int    not null default 0,
+    uses             int    not null default 0,
+    require_approval bool   not null default false,
+    revoked          bool   not null default false,
+    created_at       timestamp,
+    updated_at       timestamp,
+    primary key (id),
+    foreign key (group_id) references chat_groups (group_id),
+    foreign key (creator_id) references users (user_id)
+);
+
+create table if not exists group_join_requests
+(
+    id         bigint auto_increment,
+    group_id   bigint   not null,
+    invite_id  bigint   not null,
+    user_id    bigint   not null,
+    status     smallint not null default 1,
+    handler_id bigint,
+    created_at timestamp,
+    updated_at timestamp,
+    primary key (id),
+    key (group_id, status),
+    foreign key (group_id) references chat_groups (group_id),
+    foreign key (invite_id) references group_invites (id),
+    foreign key (user_id) references users (user_id)
+);
@@ -0,0 +1,4 @@
+-- 解散群聊
+
+alter table chat_groups
+    add column dissolved bool not null default false after member_can_pin;
@@ -0,0 +1,7 @@
+-- 全员禁言、成员禁言
+
+alter table chat_groups
+    add column mute_all bool not null default false after dissolved;
+
+alter table group_members
+    add column muted_until timestamp null after role;
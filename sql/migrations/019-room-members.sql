@@ -0,0 +1,38 @@
+-- 聊天室增加类型和成员表，回填已有聊天室的成员，不再通过聊天室名称解析成员
+
+alter table chat_rooms
+    add column type smallint not null default 1 after room_id;
+
+create table if not exists room_members
+(
+    id         bigint auto_increment,
+    room_id    bigint not null,
+    user_id    bigint not null,
+    created_at timestamp,
+    primary key (id),
+    foreign key (room_id) references chat_rooms (room_id),
+    foreign key (user_id) references users (user_id),
+    unique (room_id, user_id),
+    key (user_id)
+);
+
+update chat_rooms
+set type = 2
+where room_id in (select room_id from chat_groups);
+
+-- 群聊室名称原为g-群id，改为空，名称只用于用户聊天室去重
+update chat_rooms
+set name = null
+where type = 2;
+
+-- 用户聊天室：双方的联系人
+insert ignore into room_members (room_id, user_id, created_at)
+select room_id, owner_id, created_at
+from contacts
+where user_id is not null and user_id != 0;
+
+-- 群聊室：群成员(已解散的群保留成员，历史消息仍可查看)
+insert ignore into room_members (room_id, user_id, created_at)
+select chat_groups.room_id, group_members.user_id, group_members.created_at
+from group_members
+         join chat_groups on chat_groups.group_id = group_members.group_id;
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"ichat-go/db"
+	"ichat-go/logic/notification"
+	"ichat-go/model/dao"
+	"ichat-go/model/dto"
+	"ichat-go/model/entity"
+	"ichat-go/sched"
+	"strconv"
+	"testing"
+)
+
+// 大群成员数
+const fanoutMembers = 500
+
+func fanoutMessage() *dto.ChatMessageDto {
+	return &dto.ChatMessageDto{
+		ChatMessage: entity.ChatMessage{MessageId: 1, RoomId: 1, SenderId: 1, Text: "hello"},
+	}
+}
+
+func BenchmarkSendChatMessage(b *testing.B) {
+	db.InitForTest()
+	m := fanoutMessage()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for uid := uint64(1); uid <= fanoutMembers; uid++ {
+			notification.SendChatMessage(uid, m, true)
+		}
+	}
+}
+
+func BenchmarkSendChatMessages(b *testing.B) {
+	db.InitForTest()
+	m := fanoutMessage()
+	ms := make(map[uint64]*dto.ChatMessageDto, fanoutMembers)
+	for uid := uint64(1); uid <= fanoutMembers; uid++ {
+		ms[uid] = m
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		notification.SendChatMessages(ms, true)
+	}
+}
+
+func fanoutDeliveries() []*entity.MessageDelivery {
+	list := make([]*entity.MessageDelivery, 0, fanoutMembers)
+	for uid := uint64(1); uid <= fanoutMembers; uid++ {
+		list = append(list, &entity.MessageDelivery{
+			MessageId:  1,
+			ReceiverId: uid,
+			Status:     entity.MessageDeliveryStatusSending,
+		})
+	}
+	return list
+}
+
+// 每次在事务中插入后回滚，关闭外键检查以免依赖用户和消息数据
+func benchmarkCreateDeliveries(b *testing.B, create func(chatDao dao.ChatDao, list []*entity.MessageDelivery)) {
+	db.InitMysqlForTest()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tx := db.MysqlDB().Begin()
+		tx.Exec("set foreign_key_checks = 0")
+		create(dao.NewChatDao(tx), fanoutDeliveries())
+		tx.Exec("set foreign_key_checks = 1")
+		tx.Rollback()
+	}
+}
+
+// BenchmarkCreateDeliveriesPerRow 逐条插入投递记录，对比批量插入
+func BenchmarkCreateDeliveriesPerRow(b *testing.B) {
+	benchmarkCreateDeliveries(b, func(chatDao dao.ChatDao, list []*entity.MessageDelivery) {
+		for _, delivery := range list {
+			chatDao.CreateDeliveries([]*entity.MessageDelivery{delivery})
+		}
+	})
+}
+
+func BenchmarkCreateDeliveries(b *testing.B) {
+	benchmarkCreateDeliveries(b, func(chatDao dao.ChatDao, list []*entity.MessageDelivery) {
+		chatDao.CreateDeliveries(list)
+	})
+}
+
+func fanoutKeys() []string {
+	keys := make([]string, fanoutMembers)
+	for i := range keys {
+		keys[i] = "test:fanout:" + strconv.Itoa(i)
+	}
+	return keys
+}
+
+func BenchmarkMQState(b *testing.B) {
+	db.InitForTest()
+	keys := fanoutKeys()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, key := range keys {
+			sched.NewMQ(key).State()
+		}
+	}
+}
+
+func BenchmarkMQStates(b *testing.B) {
+	db.InitForTest()
+	keys := fanoutKeys()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sched.States(keys)
+	}
+}
+
+func TestMQStates(t *testing.T) {
+	db.InitForTest()
+	keys := fanoutKeys()[:3]
+	m := sched.NewMQ(keys[1])
+	m.SaveState(2)
+	defer m.Close(true)
+	states := sched.States(keys)
+	if len(states) != 3 || states[0] != 0 || states[1] != 2 || states[2] != 0 {
+		t.Errorf("States not working: %v", states)
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"ichat-go/config"
+	"ichat-go/errs"
+	"ichat-go/logic"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// webm/opus录音的EBML头部，浏览器MediaRecorder录制的语音格式
+var webmOpusHeader = []byte{
+	0x1A, 0x45, 0xDF, 0xA3, 0x9F,
+	0x42, 0x86, 0x81, 0x01,
+	0x42, 0xF7, 0x81, 0x01,
+	0x42, 0xF2, 0x81, 0x04,
+	0x42, 0xF3, 0x81, 0x08,
+	0x42, 0x82, 0x84, 'w', 'e', 'b', 'm',
+	0x42, 0x87, 0x81, 0x04,
+	0x42, 0x85, 0x81, 0x02,
+	0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+	0x16, 0x54, 0xAE, 0x6B, 0x8E,
+	0xAE, 0x8C,
+	0x86, 0x86, 'A', '_', 'O', 'P', 'U', 'S',
+	0x83, 0x81, 0x02,
+}
+
+func uploadAudio(t *testing.T, name string, content []byte) (path string, err any) {
+	config.App.UploadDir = t.TempDir()
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	fw, _ := w.CreateFormFile("file", name)
+	_, _ = fw.Write(content)
+	_ = w.Close()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/file/upload/audio", body)
+	c.Request.Header.Set("Content-Type", w.FormDataContentType())
+	defer func() {
+		err = recover()
+	}()
+	return logic.FileUploadAudio(c), nil
+}
+
+func TestUploadAudioWebm(t *testing.T) {
+	path, err := uploadAudio(t, "voice.webm", webmOpusHeader)
+	if err != nil {
+		t.Fatalf("webm audio rejected: %v", err)
+	}
+	if !strings.HasPrefix(path, "file/audio-") || !strings.HasSuffix(path, ".webm") {
+		t.Errorf("unexpected path %s", path)
+	}
+}
+
+func TestUploadAudioInvalid(t *testing.T) {
+	_, err := uploadAudio(t, "voice.webm", []byte("not an audio file"))
+	if err != errs.AudioTypeNotSupported {
+		t.Errorf("expected AudioTypeNotSupported, got %v", err)
+	}
+}
@@ -14,3 +14,14 @@ func TestSubstring(t *testing.T) {
 		t.Errorf("TakeFitstN not working")
 	}
 }
+
+func TestIndexAll(t *testing.T) {
+	r := strs.IndexAll("你好Go，go语言哈哈哈", "GO")
+	if len(r) != 2 || r[0] != [2]int{2, 4} || r[1] != [2]int{5, 7} {
+		t.Errorf("IndexAll not working: %v", r)
+	}
+	r = strs.IndexAll("哈哈哈", "哈哈")
+	if len(r) != 1 || r[0] != [2]int{0, 2} {
+		t.Errorf("IndexAll should not overlap: %v", r)
+	}
+}
@@ -1,6 +1,9 @@
 package strs
 
-import "unicode/utf8"
+import (
+	"unicode"
+	"unicode/utf8"
+)
 
 func TakeFirstN(s string, n int, tail ...bool) string {
 	if utf8.RuneCountInString(s) < n {
@@ -12,3 +15,29 @@ func TakeFirstN(s string, n int, tail ...bool) string {
 	}
 	return head
 }
+
+// IndexAll 忽略大小写查找sub在s中所有不重叠出现的位置，返回按rune计算的[start, end)
+func IndexAll(s string, sub string) [][2]int {
+	results := make([][2]int, 0)
+	rs := []rune(s)
+	sr := []rune(sub)
+	if len(sr) == 0 {
+		return results
+	}
+	for i := 0; i+len(sr) <= len(rs); {
+		matched := true
+		for j, r := range sr {
+			if unicode.ToLower(rs[i+j]) != unicode.ToLower(r) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			results = append(results, [2]int{i, i + len(sr)})
+			i += len(sr)
+		} else {
+			i++
+		}
+	}
+	return results
+}
@@ -57,5 +57,7 @@ func validateUrl(fl validator.FieldLevel) bool {
 	if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
 		return true
 	}
-	return strings.HasPrefix(s, "file/")
+	// 本地上传的文件路径，不允许跳出上传目录
+	return strings.HasPrefix(s, "file/") && len(s) > len("file/") &&
+		!strings.Contains(s, "..")
 }